@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/taha-yassine/sidem/internal/compare"
+	"github.com/taha-yassine/sidem/internal/parser"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	checkTemplate         string
+	checkPorcelain        bool
+	checkRequireAnnotated bool
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check [dotenv-file]",
+	Short: "Check a .env file against its template",
+	Long: `Check that a .env file declares the same keys as its template
+(.env.example next to it by default): keys missing from the file and extra
+keys not in the template are reported.
+
+With --require-annotated, variables marked with a "# ` + parser.RequiredAnnotation + `"
+comment, in the file or the template, must also have a non-empty active value.
+
+Exits with a non-zero status if a key is missing or, with --require-annotated,
+a required one is empty. With --porcelain, each difference is printed as
+KIND<TAB>KEY, KIND being MISSING, EXTRA or EMPTY. This format is stable and
+meant for scripts.`,
+	Args:          cobra.MaximumNArgs(1),
+	RunE:          runCheck,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	checkCmd.Flags().StringVar(&checkTemplate, "template", "", "template to check against (default: .env.example next to the file)")
+	checkCmd.Flags().BoolVar(&checkRequireAnnotated, "require-annotated", false, "fail if a variable annotated as required is empty or inactive")
+	checkCmd.Flags().BoolVar(&checkPorcelain, "porcelain", false, "print a stable, tab-separated output for scripts")
+	rootCmd.AddCommand(checkCmd)
+}
+
+func runCheck(cmd *cobra.Command, args []string) error {
+	filePath := filePathFromArgs(args)
+	template := checkTemplate
+	if template == "" {
+		template = filepath.Join(filepath.Dir(filePath), ".env.example")
+	}
+
+	file, err := parser.ParseFile(filePath)
+	if err != nil {
+		return err
+	}
+	tmpl, err := parser.ParseFile(template)
+	if err != nil {
+		return fmt.Errorf("error parsing template: %w", err)
+	}
+
+	diffs := compare.Check(file, tmpl)
+	if checkRequireAnnotated {
+		diffs = append(diffs, compare.Unset(file, tmpl)...)
+	}
+
+	missing, empty := 0, 0
+	for _, d := range diffs {
+		switch d.Kind {
+		case compare.Missing:
+			missing++
+		case compare.Empty:
+			empty++
+		}
+		if checkPorcelain {
+			printPorcelain(d)
+			continue
+		}
+		switch d.Kind {
+		case compare.Missing:
+			fmt.Printf("%s: missing %s (declared in %s)\n", filePath, d.Key, template)
+		case compare.Extra:
+			fmt.Printf("%s: extra %s (not in %s)\n", filePath, d.Key, template)
+		case compare.Empty:
+			fmt.Printf("%s: required %s is empty or inactive\n", filePath, d.Key)
+		}
+	}
+	switch {
+	case missing > 0 && empty > 0:
+		return fmt.Errorf("%d key(s) missing and %d required key(s) empty", missing, empty)
+	case missing > 0:
+		return fmt.Errorf("%d key(s) missing", missing)
+	case empty > 0:
+		return fmt.Errorf("%d required key(s) empty", empty)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckRequireAnnotated(t *testing.T) {
+	tests := []struct {
+		file    string
+		wantErr string
+	}{
+		{"TOKEN=abc\nPORT=\n", ""},
+		{"TOKEN=\nPORT=\n", "1 required key(s) empty"},
+		{"# TOKEN=abc\nPORT=\n", "1 required key(s) empty"},
+		{"# sidem:required\nPORT=\nTOKEN=x\n", "1 required key(s) empty"}, // Annotated in the file
+		{"PORT=\n", "1 key(s) missing"},
+	}
+	for _, tt := range tests {
+		dir := writeFiles(t, map[string]string{
+			".env":         tt.file,
+			".env.example": "# sidem:required\nTOKEN=\nPORT=\n",
+		})
+		checkRequireAnnotated = true
+		t.Cleanup(func() { checkRequireAnnotated = false })
+
+		_, err := captureStdout(t, func() error { return runCheck(checkCmd, []string{filepath.Join(dir, ".env")}) })
+		if (tt.wantErr == "" && err != nil) || (tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr))) {
+			t.Errorf("checking %q returned %v, want %q", tt.file, err, tt.wantErr)
+		}
+
+		// Without the flag, only the keys are checked
+		checkRequireAnnotated = false
+		if _, err := captureStdout(t, func() error { return runCheck(checkCmd, []string{filepath.Join(dir, ".env")}) }); err != nil && !strings.Contains(tt.wantErr, "missing") {
+			t.Errorf("checking %q without --require-annotated returned %v", tt.file, err)
+		}
+	}
+}
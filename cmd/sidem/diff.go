@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/taha-yassine/sidem/internal/compare"
+	"github.com/taha-yassine/sidem/internal/parser"
+
+	"github.com/spf13/cobra"
+)
+
+var diffPorcelain bool
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <base-file> <other-file>",
+	Short: "Compare the active variables of two .env files",
+	Long: `Compare the active variables of two .env files, listing those added,
+removed or changed in the other file.
+
+With --porcelain, each difference is printed as KIND<TAB>KEY, KIND being
+ADDED, REMOVED or CHANGED. This format is stable and meant for scripts.`,
+	Args:          cobra.ExactArgs(2),
+	RunE:          runDiff,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	diffCmd.Flags().BoolVar(&diffPorcelain, "porcelain", false, "print a stable, tab-separated output for scripts")
+	rootCmd.AddCommand(diffCmd)
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	base, err := parser.ParseFile(args[0])
+	if err != nil {
+		return err
+	}
+	other, err := parser.ParseFile(args[1])
+	if err != nil {
+		return err
+	}
+
+	for _, d := range compare.Diff(base, other) {
+		if diffPorcelain {
+			printPorcelain(d)
+			continue
+		}
+		switch d.Kind {
+		case compare.Added:
+			fmt.Printf("+ %s=%s\n", d.Key, parser.QuoteValue(d.New))
+		case compare.Removed:
+			fmt.Printf("- %s=%s\n", d.Key, parser.QuoteValue(d.Old))
+		case compare.Changed:
+			fmt.Printf("~ %s: %s -> %s\n", d.Key, parser.QuoteValue(d.Old), parser.QuoteValue(d.New))
+		}
+	}
+	return nil
+}
+
+// printPorcelain prints a difference in the stable KIND<TAB>KEY format.
+func printPorcelain(d compare.Difference) {
+	fmt.Printf("%s\t%s\n", d.Kind, d.Key)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/taha-yassine/sidem/internal/atomicfile"
+	"github.com/taha-yassine/sidem/internal/parser"
+
+	"github.com/spf13/cobra"
+)
+
+var exampleOutput string
+
+var exampleCmd = &cobra.Command{
+	Use:   "example [dotenv-file]",
+	Short: "Write a template of a .env file with its values emptied",
+	Long: `Write a template of a .env file, such as .env.example, keeping keys and
+comments but emptying every value (KEY=).
+
+The result is written to the file given with -o, or printed if omitted.`,
+	Args:          cobra.MaximumNArgs(1),
+	RunE:          runExample,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	exampleCmd.Flags().StringVarP(&exampleOutput, "output", "o", "", "file to write the template to")
+	rootCmd.AddCommand(exampleCmd)
+}
+
+func runExample(cmd *cobra.Command, args []string) error {
+	parsedData, err := parser.ParseFile(filePathFromArgs(args))
+	if err != nil {
+		return err
+	}
+	content := parser.RenderBlanked(parsedData)
+
+	if exampleOutput == "" {
+		_, err := fmt.Print(content)
+		return err
+	}
+	if err := atomicfile.Write(exampleOutput, []byte(content)); err != nil {
+		return fmt.Errorf("failed to write to file %s: %w", exampleOutput, err)
+	}
+	return nil
+}
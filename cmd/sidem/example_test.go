@@ -0,0 +1,29 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExample(t *testing.T) {
+	dir := writeFiles(t, map[string]string{".env": "# Database\nDB_HOST=localhost\n# DB_HOST=db.prod\nAPI_KEY=secret\n"})
+	const want = "# Database\nDB_HOST=\n# DB_HOST=\nAPI_KEY=\n"
+
+	got, err := captureStdout(t, func() error { return runExample(exampleCmd, []string{filepath.Join(dir, ".env")}) })
+	if err != nil || got != want {
+		t.Errorf("example printed %q, %v, want %q", got, err, want)
+	}
+
+	output := filepath.Join(dir, ".env.example")
+	exampleOutput = output
+	t.Cleanup(func() { exampleOutput = "" })
+	got, err = captureStdout(t, func() error { return runExample(exampleCmd, []string{filepath.Join(dir, ".env")}) })
+	if err != nil || got != "" {
+		t.Fatalf("example -o printed %q, %v, want nothing", got, err)
+	}
+	written, err := os.ReadFile(output)
+	if err != nil || string(written) != want {
+		t.Errorf("example -o wrote %q, %v, want %q", written, err, want)
+	}
+}
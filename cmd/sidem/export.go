@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/taha-yassine/sidem/internal/export"
+	"github.com/taha-yassine/sidem/internal/parser"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportFormat       string
+	exportDiffAgainst  string
+	allowCommandSubst  bool
+	commandTimeout     time.Duration
+	exportKeepFragment bool
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export [dotenv-file]",
+	Short: "Print the active variables of a .env file",
+	Long: `Print the active variables of a .env file in the chosen format.
+
+With --diff-against, only the variables whose active value differs from
+(or is absent in) the base file are printed, which is handy to generate
+environment-specific overrides.
+
+With --allow-command-subst, $(command) substitutions in values are replaced
+by the output of the command. This runs arbitrary code from the file, only
+use it on files you trust. Saving from the TUI always keeps the literal.`,
+	Args:          cobra.MaximumNArgs(1),
+	RunE:          runExport,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", export.FormatEnv, "output format: env or json")
+	exportCmd.Flags().StringVar(&exportDiffAgainst, "diff-against", "", "only print variables differing from this base file")
+	exportCmd.Flags().BoolVar(&allowCommandSubst, "allow-command-subst", false, "run $(command) substitutions in values and use their output (executes code from the file)")
+	exportCmd.Flags().DurationVar(&commandTimeout, "command-timeout", 5*time.Second, "kill substituted commands running longer than this")
+	exportCmd.Flags().BoolVar(&exportKeepFragment, "keep-fragment", false, "keep ' #...' after an unquoted value as part of it instead of dropping it as a comment")
+	rootCmd.AddCommand(exportCmd)
+}
+
+// printJSON prints the active variables of the .env file at filePath as a JSON
+// object, for the --json flag of the root command.
+func printJSON(filePath string, opts parser.Options) error {
+	parsedData, err := parser.ParseFileWithOptions(filePath, opts)
+	if err != nil {
+		return err
+	}
+	return export.Write(os.Stdout, export.FormatJSON, export.Active(parsedData))
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	opts := parser.Options{KeepFragment: exportKeepFragment}
+	parsedData, err := parser.ParseFileWithOptions(filePathFromArgs(args), opts)
+	if err != nil {
+		return err
+	}
+	vars := export.Active(parsedData)
+
+	if exportDiffAgainst != "" {
+		base, err := parser.ParseFileWithOptions(exportDiffAgainst, opts)
+		if err != nil {
+			return fmt.Errorf("error parsing base file: %w", err)
+		}
+		vars = export.Diff(vars, export.Active(base))
+	}
+
+	if allowCommandSubst {
+		if vars, err = export.SubstituteCommands(vars, commandTimeout); err != nil {
+			return err
+		}
+	}
+
+	return export.Write(os.Stdout, exportFormat, vars)
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestExportDiffAgainst(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"base.env":    "SAME=1\nCHANGED=old\nREMOVED=x\n",
+		"staging.env": "SAME=1\nCHANGED=new value\nADDED=y\n",
+	})
+	exportDiffAgainst = filepath.Join(dir, "base.env")
+	t.Cleanup(func() { exportDiffAgainst = "" })
+
+	got, err := captureStdout(t, func() error {
+		return runExport(exportCmd, []string{filepath.Join(dir, "staging.env")})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "CHANGED=\"new value\"\nADDED=y\n"; got != want {
+		t.Errorf("printed %q, want %q", got, want)
+	}
+
+	// A missing base file is an error, not an empty diff
+	exportDiffAgainst = filepath.Join(dir, "missing.env")
+	if _, err := captureStdout(t, func() error {
+		return runExport(exportCmd, []string{filepath.Join(dir, "staging.env")})
+	}); err == nil {
+		t.Error("no error for a missing base file")
+	}
+}
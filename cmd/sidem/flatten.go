@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/taha-yassine/sidem/internal/atomicfile"
+	"github.com/taha-yassine/sidem/internal/parser"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	flattenOutput       string
+	flattenKeepFragment bool
+)
+
+var flattenCmd = &cobra.Command{
+	Use:   "flatten [dotenv-file]",
+	Short: "Write a .env file holding only the active variables",
+	Long: `Resolve a .env file to its final configuration: only the active occurrence
+of each variable is kept, commented alternatives and comments are dropped.
+
+The result is written to the file given with -o, or printed if omitted.`,
+	Args:          cobra.MaximumNArgs(1),
+	RunE:          runFlatten,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	flattenCmd.Flags().StringVarP(&flattenOutput, "output", "o", "", "file to write the flattened content to")
+	flattenCmd.Flags().BoolVar(&flattenKeepFragment, "keep-fragment", false, "keep ' #...' after an unquoted value as part of it instead of dropping it as a comment")
+	rootCmd.AddCommand(flattenCmd)
+}
+
+func runFlatten(cmd *cobra.Command, args []string) error {
+	parsedData, err := parser.ParseFileWithOptions(filePathFromArgs(args), parser.Options{KeepFragment: flattenKeepFragment})
+	if err != nil {
+		return err
+	}
+	content := parser.RenderActive(parsedData)
+
+	if flattenOutput == "" {
+		_, err := fmt.Print(content)
+		return err
+	}
+	if err := atomicfile.Write(flattenOutput, []byte(content)); err != nil {
+		return fmt.Errorf("failed to write to file %s: %w", flattenOutput, err)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFlattenKeepFragment(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, ".env")
+	content := "# Service\nURL=http://host/ #section # docs\n# URL=http://old/\nPORT=8080 #\n"
+	if err := os.WriteFile(input, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		keepFragment bool
+		want         string
+	}{
+		{false, "URL=http://host/\nPORT=8080\n"},
+		{true, "URL=http://host/ #section # docs\nPORT=8080 #\n"},
+	}
+	for _, tt := range tests {
+		flattenOutput = filepath.Join(dir, "flat.env")
+		flattenKeepFragment = tt.keepFragment
+		t.Cleanup(func() { flattenOutput, flattenKeepFragment = "", false })
+
+		if err := runFlatten(flattenCmd, []string{input}); err != nil {
+			t.Fatal(err)
+		}
+		got, err := os.ReadFile(flattenOutput)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("keep fragment %v: flattened to %q, want %q", tt.keepFragment, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/taha-yassine/sidem/internal/parser"
+
+	"github.com/spf13/cobra"
+)
+
+var getIgnoreCase bool
+
+var getCmd = &cobra.Command{
+	Use:   "get [dotenv-file] KEY",
+	Short: "Print the active value of a variable",
+	Long: `Print the active value of a variable, failing if the variable is not
+declared or has no active occurrence.
+
+With --ignore-case, KEY also matches a key differing only in case.`,
+	Args:          cobra.RangeArgs(1, 2),
+	RunE:          runGet,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	getCmd.Flags().BoolVar(&getIgnoreCase, "ignore-case", false, "match the key case-insensitively")
+	rootCmd.AddCommand(getCmd)
+}
+
+func runGet(cmd *cobra.Command, args []string) error {
+	filePath := filePathFromArgs(args[:len(args)-1])
+	parsedData, err := parser.ParseFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	value, err := activeValue(parsedData, filePath, args[len(args)-1], getIgnoreCase)
+	if err != nil {
+		return err
+	}
+	fmt.Println(value)
+	return nil
+}
+
+// activeValue returns the active value of key in the parsed file at filePath,
+// failing if the variable is not declared or has no active occurrence.
+func activeValue(parsedData *parser.ParsedData, filePath, key string, ignoreCase bool) (string, error) {
+	key, ok, err := parsedData.LookupKey(key, ignoreCase)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fmt.Errorf("%s is not declared in %s", key, filePath)
+	}
+	line := parsedData.VariableGroups[key].ActiveLine()
+	if line == nil {
+		return "", fmt.Errorf("%s is not active in %s", key, filePath)
+	}
+	return line.Value, nil
+}
+
+// printValues prints the active value of each key in the .env file at
+// filePath, one per line in order, for the --get flag of the root command.
+// Nothing is printed if one of them is missing or inactive.
+func printValues(filePath string, keys []string, opts parser.Options) error {
+	parsedData, err := parser.ParseFileWithOptions(filePath, opts)
+	if err != nil {
+		return err
+	}
+	values := make([]string, 0, len(keys))
+	for _, key := range keys {
+		value, err := activeValue(parsedData, filePath, key, false)
+		if err != nil {
+			return err
+		}
+		values = append(values, value)
+	}
+	for _, value := range values {
+		fmt.Println(value)
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGetIgnoreCase(t *testing.T) {
+	path := filepath.Join(writeFiles(t, map[string]string{".env": "Path=/bin\nhome=/root\nHOME=/home\n"}), ".env")
+	getIgnoreCase = true
+	t.Cleanup(func() { getIgnoreCase = false })
+
+	got, err := captureStdout(t, func() error { return runGet(getCmd, []string{path, "PATH"}) })
+	if err != nil || got != "/bin\n" {
+		t.Errorf("get PATH printed %q, %v, want /bin", got, err)
+	}
+
+	_, err = captureStdout(t, func() error { return runGet(getCmd, []string{path, "Home"}) })
+	if err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("get Home returned %v, want an ambiguous match error", err)
+	}
+}
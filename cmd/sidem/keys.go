@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/taha-yassine/sidem/internal/parser"
+
+	"github.com/spf13/cobra"
+)
+
+var keysActiveOnly bool
+
+var keysCmd = &cobra.Command{
+	Use:           "keys [dotenv-file]",
+	Short:         "List the variable keys of a .env file",
+	Long:          `Print each variable key of a .env file, one per line, in the order they first appear.`,
+	Args:          cobra.MaximumNArgs(1),
+	RunE:          runKeys,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	keysCmd.Flags().BoolVar(&keysActiveOnly, "active-only", false, "only list variables with an active occurrence")
+	rootCmd.AddCommand(keysCmd)
+}
+
+func runKeys(cmd *cobra.Command, args []string) error {
+	parsedData, err := parser.ParseFile(filePathFromArgs(args))
+	if err != nil {
+		return err
+	}
+	for _, key := range parsedData.GroupOrder {
+		if keysActiveOnly && parsedData.VariableGroups[key].ActiveLine() == nil {
+			continue
+		}
+		fmt.Println(key)
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestKeys(t *testing.T) {
+	path := filepath.Join(writeFiles(t, map[string]string{".env": "# B=0\nA=1\nB=2\n# C=3\nA=4\nD=\n"}), ".env")
+
+	got, err := captureStdout(t, func() error { return runKeys(keysCmd, []string{path}) })
+	if want := "B\nA\nC\nD\n"; err != nil || got != want {
+		t.Errorf("keys printed %q, %v, want %q", got, err, want)
+	}
+
+	keysActiveOnly = true
+	t.Cleanup(func() { keysActiveOnly = false })
+	got, err = captureStdout(t, func() error { return runKeys(keysCmd, []string{path}) })
+	if want := "B\nA\nD\n"; err != nil || got != want {
+		t.Errorf("keys --active-only printed %q, %v, want %q", got, err, want)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/taha-yassine/sidem/internal/lint"
+	"github.com/taha-yassine/sidem/internal/parser"
+
+	"github.com/spf13/cobra"
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint [dotenv-file]",
+	Short: "Report structural issues of a .env file",
+	Long: `Check that a .env file parses cleanly and report its structural issues:
+unterminated quotes, keys active more than once, assignments with an invalid
+key (treated as comments), keys differing only by case and trailing whitespace.
+
+Exits with a non-zero status if any is found, e.g. to use it as a pre-commit hook.`,
+	Args:          cobra.MaximumNArgs(1),
+	RunE:          runLint,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+}
+
+func runLint(cmd *cobra.Command, args []string) error {
+	filePath := filePathFromArgs(args)
+
+	data, err := parser.ParseFile(filePath)
+	if err != nil {
+		fmt.Printf("%s: %v\n", filePath, err)
+		return fmt.Errorf("%s does not parse", filePath)
+	}
+
+	issues := lint.Check(data)
+	for _, issue := range issues {
+		fmt.Printf("%s:%d: %s\n", filePath, issue.Line, issue.Message)
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("found %d issue(s)", len(issues))
+	}
+	return nil
+}
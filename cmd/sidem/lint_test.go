@@ -0,0 +1,43 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"clean.env":        "# Database\nDB_HOST=localhost\n# DB_HOST=remote\n",
+		"issues.env":       "A=1\nA=2\nMY-KEY=x \n",
+		"unterminated.env": "A=\"never closed\nB=2\n",
+	})
+
+	tests := []struct {
+		file    string
+		wantErr string
+		want    []string // Printed lines, after the file path
+	}{
+		{"clean.env", "", nil},
+		{"issues.env", "found 3 issue(s)", []string{
+			":2: A is already active above, only the first value is used",
+			":3: invalid key, the line is treated as a comment",
+			":3: trailing whitespace",
+		}},
+		{"unterminated.env", "does not parse", []string{": error parsing line 1: unterminated double-quoted value"}},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.file)
+		got, err := captureStdout(t, func() error { return runLint(lintCmd, []string{path}) })
+		if (err == nil) != (tt.wantErr == "") || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("linting %s returned %v, want %q", tt.file, err, tt.wantErr)
+		}
+		want := ""
+		for _, line := range tt.want {
+			want += path + line + "\n"
+		}
+		if got != want {
+			t.Errorf("linting %s printed %q, want %q", tt.file, got, want)
+		}
+	}
+}
@@ -1,10 +1,16 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/taha-yassine/sidem/internal/clipboard"
+	"github.com/taha-yassine/sidem/internal/config"
 	"github.com/taha-yassine/sidem/internal/parser"
 	"github.com/taha-yassine/sidem/internal/tui"
 	"github.com/taha-yassine/sidem/internal/watcher"
@@ -14,49 +20,194 @@ import (
 )
 
 var rootCmd = &cobra.Command{
-	Use:   "sidem [dotenv-file]",
+	Use:   "sidem [dotenv-file...]",
 	Short: "A TUI application to manage .env files",
 	Long: `sidem provides a terminal user interface
 for viewing, editing, and managing variables within a .env file.
 
-If [dotenv-file] is not provided, it defaults to '.env' in the current directory.`,
-	Args:                  cobra.MaximumNArgs(1), // Allow 0 or 1 argument
+If [dotenv-file] is not provided, it defaults to $SIDEM_ENV_FILE if set,
+or to '.env' in the current directory. Several files are opened in tabs,
+switched with tab and shift+tab.`,
+	Args:                  cobra.ArbitraryArgs,
 	Run:                   runApplication,
 	DisableFlagsInUseLine: true,
 }
 
-func runApplication(cmd *cobra.Command, args []string) {
-	// 1. Determine the target .env file path
-	filePath := ".env" // Default
+// Command-line flags
+var (
+	copyQuoted         bool
+	autoSave           time.Duration
+	poll               bool
+	noBackup           bool
+	backupDir          string
+	pollInterval       time.Duration
+	keyCase            string
+	spacing            string
+	commentMarker      string
+	showEnv            bool
+	configPath         string
+	compact            bool
+	alignValues        bool
+	maskSecrets        bool
+	envOverrides       []string
+	clipboardBackend   string
+	statusTimeout      time.Duration
+	contextLines       int
+	groupContext       bool
+	errorOnUnsavedQuit bool
+	annotateChanges    bool
+	trimTrailingBlank  bool
+	warnValueLength    int
+	readOnly           bool
+	largeFileSize      int
+	create             bool
+	jsonOutput         bool
+	getKeys            []string
+	hideSingleRadio    bool
+	quiet              bool
+	noFlash            bool
+)
+
+func init() {
+	defaultConfigPath, _ := config.DefaultPath()
+	rootCmd.Flags().StringVar(&configPath, "config", defaultConfigPath, "path to the configuration file")
+	rootCmd.Flags().BoolVar(&copyQuoted, "copy-quoted", false, "copy values in their quoted .env form when they contain special characters")
+	rootCmd.Flags().StringVar(&keyCase, "key-case", string(parser.KeyCasePreserve), "normalize keys on save: upper, lower or preserve")
+	rootCmd.Flags().StringVar(&spacing, "spacing", string(parser.SpacingPreserve), "normalize spaces around '=' on save: compact (KEY=value), spaced (KEY = value) or preserve")
+	rootCmd.Flags().StringVar(&commentMarker, "comment-marker", parser.DefaultCommentMarker, "comment marker of the file's dialect: # or ; (# is always recognized)")
+	rootCmd.Flags().BoolVar(&showEnv, "show-env", false, "flag variables already set in the process environment, showing their live value")
+	rootCmd.Flags().BoolVar(&compact, "compact", false, "show groups with a single value on one row")
+	rootCmd.Flags().BoolVar(&alignValues, "align-values", false, "align the values of compact rows in a column (implies --compact)")
+	rootCmd.Flags().BoolVar(&noFlash, "no-flash", false, "don't briefly highlight the value made active on toggle")
+	rootCmd.Flags().BoolVar(&hideSingleRadio, "hide-single-radio", false, "hide the radio column of groups with a single value, where there is nothing to choose")
+	rootCmd.Flags().BoolVar(&maskSecrets, "mask-secrets", false, "hide the values of variables whose name looks sensitive (KEY, SECRET, TOKEN, PASSWORD...)")
+	rootCmd.Flags().StringArrayVar(&envOverrides, "env", nil, "override a variable in memory only, as KEY=VALUE (repeatable)")
+	rootCmd.Flags().StringVar(&clipboardBackend, "clipboard", string(clipboard.BackendAuto), "clipboard backend: auto, osc52 or system")
+	rootCmd.Flags().DurationVar(&autoSave, "autosave", 0, "save automatically after this delay since the last change (e.g. 2s, 0 disables)")
+	rootCmd.Flags().DurationVar(&statusTimeout, "status-timeout", 2*time.Second, "how long transient status messages stay (0 keeps them until the next key press)")
+	rootCmd.Flags().IntVar(&contextLines, "context-lines", 2, "rows kept visible above and below the cursor when scrolling")
+	rootCmd.Flags().BoolVar(&groupContext, "group-context", false, "when landing on a group, keep its occurrences and the next group visible if they fit")
+	rootCmd.Flags().BoolVar(&errorOnUnsavedQuit, "error-on-unsaved-quit", false, fmt.Sprintf("exit with status %d when quitting without saving changes", tui.ExitCodeUnsaved))
+	rootCmd.Flags().BoolVar(&trimTrailingBlank, "trim-trailing-blank-lines", false, "remove blank lines at the end of the file on save, keeping a single final newline")
+	rootCmd.Flags().IntVar(&warnValueLength, "warn-value-length", 0, "flag values longer than this many characters, often accidental pastes (0 disables)")
+	rootCmd.Flags().BoolVar(&noBackup, "no-backup", false, "don't back up the file before saving")
+	rootCmd.Flags().StringVar(&backupDir, "backup-dir", "", "write timestamped backups to this directory instead of a .bak next to the file")
+	rootCmd.Flags().BoolVar(&poll, "poll", false, "watch the file by polling instead of file system events, e.g. on network filesystems")
+	rootCmd.Flags().DurationVar(&pollInterval, "poll-interval", watcher.DefaultPollInterval, "how often the file is checked for changes when polling")
+	rootCmd.Flags().BoolVar(&readOnly, "read-only", false, "open the file for viewing only: selecting, editing, adding, deleting and saving are disabled")
+	rootCmd.Flags().BoolVar(&jsonOutput, "json", false, "print the active variables as a JSON object and exit instead of opening the TUI")
+	rootCmd.Flags().StringArrayVar(&getKeys, "get", nil, "print the active value of this key and exit instead of opening the TUI (repeatable)")
+	rootCmd.MarkFlagsMutuallyExclusive("json", "get")
+	rootCmd.Flags().BoolVar(&create, "create", false, "start with an empty file if it doesn't exist, created on save")
+	rootCmd.Flags().IntVar(&largeFileSize, "large-file-size", 5, "size in MiB above which opening read-only is offered (0 disables the check)")
+	rootCmd.Flags().BoolVar(&quiet, "quiet", false, "don't print the exit message on stdout")
+	rootCmd.Flags().BoolVar(&annotateChanges, "annotate-changes", false, "write a '# last-changed: <time> by <user>' comment above variables changed through the TUI")
+}
+
+// envFileVar is the environment variable overriding the default .env file path.
+const envFileVar = "SIDEM_ENV_FILE"
+
+// filePathFromArgs returns the .env file path given as first argument,
+// falling back to $SIDEM_ENV_FILE, then to '.env'.
+func filePathFromArgs(args []string) string {
 	if len(args) > 0 {
-		filePath = args[0] // Use the provided argument
+		return args[0] // Use the provided argument
+	}
+	if envFile := os.Getenv(envFileVar); envFile != "" {
+		return envFile
 	}
+	return ".env" // Default
+}
 
-	// Configure logging (optional, useful for watcher debugging)
-	// log.SetOutput(os.Stderr)
-	log.SetFlags(log.LstdFlags | log.Lshortfile)
+// parseOverrides parses KEY=VALUE pairs given with --env.
+func parseOverrides(pairs []string) (map[string]string, error) {
+	overrides := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --env value %q (expected KEY=VALUE)", pair)
+		}
+		overrides[key] = value
+	}
+	return overrides, nil
+}
+
+// isLargeFile reports whether a file of size bytes exceeds the limit in MiB
+// above which opening it read-only is offered. A limit of 0 disables the check.
+func isLargeFile(size int64, limitMiB int) bool {
+	return limitMiB > 0 && size > int64(limitMiB)<<20
+}
 
-	// 2. Check if the file exists before parsing
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		fmt.Fprintf(os.Stderr, "Error: File not found at %s\n", filePath)
+// confirmReadOnly warns that the file is large and asks whether to open it
+// read-only, the default. Without a terminal to ask on, it opens read-only.
+func confirmReadOnly(filePath string, size int64) bool {
+	fmt.Fprintf(os.Stderr, "Warning: %s is %.1f MiB, over the %d MiB safe size, editing it may be slow.\n", filePath, float64(size)/(1<<20), largeFileSize)
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		fmt.Fprintln(os.Stderr, "Opening it read-only.")
+		return true
+	}
+	fmt.Fprint(os.Stderr, "Open it read-only? [Y/n] ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return !strings.EqualFold(strings.TrimSpace(answer), "n")
+}
+
+// confirmCreate asks whether to start editing the missing file at filePath.
+// Without a terminal to ask on, it doesn't.
+func confirmCreate(filePath string) bool {
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	fmt.Fprintf(os.Stderr, "%s does not exist. Create it? [y/N] ", filePath)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.EqualFold(strings.TrimSpace(answer), "y")
+}
+
+// flagsSet returns which of the named flags were given explicitly on the command line.
+func flagsSet(cmd *cobra.Command, names ...string) map[string]bool {
+	set := make(map[string]bool)
+	for _, name := range names {
+		set[name] = cmd.Flags().Changed(name)
+	}
+	return set
+}
+
+// openModel checks, parses and starts watching the .env file at filePath, and
+// creates its TUI model. It exits the program on error.
+func openModel(filePath string, opts tui.Options) tui.Model {
+	parserOpts := parser.Options{CommentMarker: opts.CommentMarker}
+
+	// Check if the file exists before parsing
+	var parsedData *parser.ParsedData
+	info, err := os.Stat(filePath)
+	switch {
+	case os.IsNotExist(err) && !opts.ReadOnly && (create || confirmCreate(filePath)):
+		// Start empty, saving creates the file
+		parsedData, err = parser.ParseWithOptions(strings.NewReader(""), filePath, parserOpts)
+	case os.IsNotExist(err):
+		fmt.Fprintf(os.Stderr, "Error: File not found at %s (use --create to start a new one)\n", filePath)
 		os.Exit(1)
-	} else if err != nil {
+	case err != nil:
 		fmt.Fprintf(os.Stderr, "Error checking file %s: %v\n", filePath, err)
 		os.Exit(1)
+	default:
+		if !opts.ReadOnly && isLargeFile(info.Size(), largeFileSize) {
+			opts.ReadOnly = confirmReadOnly(filePath, info.Size())
+		}
+		parsedData, err = parser.ParseFileWithOptions(filePath, parserOpts)
 	}
-
-	// 3. Parse the .env file
-	parsedData, err := parser.ParseFile(filePath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error parsing file %s: %v\n", filePath, err)
 		os.Exit(1)
 	}
+	if opts.ReadOnly {
+		opts.AutoSave = 0
+	}
 
 	// Optional: Print debug info if needed
 	// parsedData.PrintDebug()
 
-	// 4. Create the watcher
-	w, err := watcher.New()
+	// Create the watcher, external changes are reloaded even in read-only mode
+	w, err := watcher.New(opts.Watch)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error creating file watcher: %v\n", err)
 		os.Exit(1)
@@ -64,19 +215,143 @@ func runApplication(cmd *cobra.Command, args []string) {
 	// Defer closing resources isn't straightforward with Bubble Tea managing the loop.
 	// The watcher context will be cancelled in the TUI model's quit handling.
 
-	// 5. Initialize the Bubble Tea model
-	initialModel := tui.InitialModel(filePath, parsedData, w)
+	return tui.InitialModel(filePath, parsedData, w, opts)
+}
+
+func runApplication(cmd *cobra.Command, args []string) {
+	// 1. Determine the target .env file paths
+	filePaths := args
+	if len(filePaths) == 0 {
+		filePaths = []string{filePathFromArgs(args)}
+	}
 
-	// 6. Create and run the Bubble Tea program
+	// Configure logging (optional, useful for watcher debugging)
+	// log.SetOutput(os.Stderr)
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+
+	kc, err := parser.ParseKeyCase(keyCase)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	sp, err := parser.ParseSpacing(spacing)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	marker, err := parser.ParseCommentMarker(commentMarker)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if jsonOutput || len(getKeys) > 0 {
+		if len(filePaths) > 1 {
+			fmt.Fprintln(os.Stderr, "Error: --json and --get read a single file")
+			os.Exit(1)
+		}
+		parserOpts := parser.Options{CommentMarker: marker}
+		if jsonOutput {
+			err = printJSON(filePaths[0], parserOpts)
+		} else {
+			err = printValues(filePaths[0], getKeys, parserOpts)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	clip, err := clipboard.ParseBackend(clipboardBackend)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	overrides, err := parseOverrides(envOverrides)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// 2. Gather the preferences shared by every file
+	opts := tui.Options{
+		CopyQuoted:         copyQuoted,
+		AutoSave:           autoSave,
+		KeyCase:            kc,
+		Spacing:            sp,
+		CommentMarker:      marker,
+		ShowEnv:            showEnv,
+		Compact:            compact || alignValues,
+		AlignValues:        alignValues,
+		MaskSecrets:        maskSecrets,
+		Overrides:          overrides,
+		Clipboard:          clip,
+		StatusTimeout:      statusTimeout,
+		ContextLines:       contextLines,
+		GroupContext:       groupContext,
+		ErrorOnUnsavedQuit: errorOnUnsavedQuit,
+		AnnotateChanges:    annotateChanges,
+		TrimTrailingBlank:  trimTrailingBlank,
+		WarnValueLength:    warnValueLength,
+		ReadOnly:           readOnly,
+		Watch:              watcher.Options{Poll: poll, PollInterval: pollInterval},
+		NoBackup:           noBackup,
+		BackupDir:          backupDir,
+		HideSingleRadio:    hideSingleRadio,
+		NoFlash:            noFlash,
+		ConfigPath:         configPath,
+		FlagsSet:           flagsSet(cmd, "status-timeout", "mask-secrets"),
+	}.WithConfig(cfg)
+
+	// 3. Open each file, several of them in tabs
+	models := make([]tui.Model, 0, len(filePaths))
+	for _, filePath := range filePaths {
+		models = append(models, openModel(filePath, opts))
+	}
+	var initialModel tea.Model = models[0]
+	if len(models) > 1 {
+		initialModel = tui.NewTabs(models...)
+	}
+
+	// 4. Create and run the Bubble Tea program
 	p := tea.NewProgram(initialModel, tea.WithAltScreen()) // Enable AltScreen
 
-	if _, err := p.Run(); err != nil {
+	finalModel, err := p.Run()
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error running program: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Exit successfully
-	fmt.Println("sidem exited.")
+	printExitMessage(os.Stdout)
+	if code := exitCode(finalModel); code != 0 {
+		os.Exit(code)
+	}
+}
+
+// exitCode returns the exit status chosen by the model the TUI ended with,
+// e.g. tui.ExitCodeUnsaved, or 0 if it doesn't choose one.
+func exitCode(finalModel tea.Model) int {
+	if m, ok := finalModel.(interface{ ExitCode() int }); ok {
+		return m.ExitCode()
+	}
+	return 0
+}
+
+// printExitMessage prints the message shown once the TUI exits, unless --quiet is set.
+func printExitMessage(w io.Writer) {
+	if !quiet {
+		fmt.Fprintln(w, "sidem exited.")
+	}
 }
 
 func main() {
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/taha-yassine/sidem/internal/parser"
+	"github.com/taha-yassine/sidem/internal/tui"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestIsLargeFile(t *testing.T) {
+	tests := []struct {
+		size     int64
+		limitMiB int
+		want     bool
+	}{
+		{5 << 20, 5, false}, // Exactly at the limit
+		{5<<20 + 1, 5, true},
+		{1 << 30, 0, false}, // Check disabled
+		{0, 1, false},
+	}
+	for _, tt := range tests {
+		if got := isLargeFile(tt.size, tt.limitMiB); got != tt.want {
+			t.Errorf("isLargeFile(%d, %d) = %v, want %v", tt.size, tt.limitMiB, got, tt.want)
+		}
+	}
+}
+
+func TestConfirmReadOnlyWithoutTerminal(t *testing.T) {
+	// Standard input is a pipe, there is no one to ask
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	w.Close()
+	stdin, stderr := os.Stdin, os.Stderr
+	os.Stdin = r
+	os.Stderr, err = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		os.Stderr.Close()
+		os.Stdin, os.Stderr = stdin, stderr
+	}()
+
+	if !confirmReadOnly("big.env", 6<<20) {
+		t.Error("a large file opened without a terminal isn't read-only")
+	}
+}
+
+func TestQuietExitMessage(t *testing.T) {
+	var out strings.Builder
+	printExitMessage(&out)
+	if out.String() != "sidem exited.\n" {
+		t.Errorf("printed %q by default", out.String())
+	}
+
+	quiet = true
+	t.Cleanup(func() { quiet = false })
+	out.Reset()
+	printExitMessage(&out)
+	if out.String() != "" {
+		t.Errorf("printed %q with --quiet", out.String())
+	}
+}
+
+func TestFilePathFromArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		envFile string
+		want    string
+	}{
+		{"argument", []string{"app.env"}, "", "app.env"},
+		{"argument over variable", []string{"app.env"}, "config/.env", "app.env"},
+		{"variable", nil, "config/.env", "config/.env"},
+		{"default", nil, "", ".env"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(envFileVar, tt.envFile)
+			if got := filePathFromArgs(tt.args); got != tt.want {
+				t.Errorf("filePathFromArgs(%q) with %s=%q = %q, want %q", tt.args, envFileVar, tt.envFile, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	dir := writeFiles(t, map[string]string{".env": "A=1\n# A=2\n", "other.env": "B=1\n"})
+	open := func(name string, opts tui.Options) tui.Model {
+		path := filepath.Join(dir, name)
+		data, err := parser.ParseFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tui.InitialModel(path, data, nil, opts)
+	}
+	send := func(m tea.Model, keys ...string) tea.Model {
+		for _, key := range keys {
+			m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+		}
+		return m
+	}
+	strict := tui.Options{ErrorOnUnsavedQuit: true}
+
+	tests := []struct {
+		name  string
+		model tea.Model
+		want  int
+	}{
+		{"clean quit", send(open(".env", strict), "q"), 0},
+		{"quit without saving", send(open(".env", strict), "j", "j", " ", "q", "n"), tui.ExitCodeUnsaved},
+		{"quit without saving, flag unset", send(open(".env", tui.Options{}), "j", "j", " ", "q", "n"), 0},
+		{"quit cancelled", send(open(".env", strict), "j", "j", " ", "q", "c"), 0},
+		{"one tab unsaved", tui.NewTabs(open("other.env", strict), send(open(".env", strict), "j", "j", " ", "q", "n").(tui.Model)), tui.ExitCodeUnsaved},
+		{"no exit code", nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.model); got != tt.want {
+				t.Errorf("exitCode = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
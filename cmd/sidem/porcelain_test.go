@@ -0,0 +1,82 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// captureStdout returns what fn prints to the standard output.
+func captureStdout(t *testing.T, fn func() error) (string, error) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan string)
+	go func() {
+		content, _ := io.ReadAll(r)
+		output <- string(content)
+	}()
+	fnErr := fn()
+	w.Close()
+	return <-output, fnErr
+}
+
+// writeFiles writes each content to the named file in a temporary directory,
+// returning the directory.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestDiffPorcelain(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"base.env":  "SAME=1\nCHANGED=old\nREMOVED=x\n# OFF=1\n",
+		"other.env": "SAME=1\nCHANGED=new value\nADDED=y\nOFF=1\n",
+	})
+	diffPorcelain = true
+	t.Cleanup(func() { diffPorcelain = false })
+
+	got, err := captureStdout(t, func() error {
+		return runDiff(diffCmd, []string{filepath.Join(dir, "base.env"), filepath.Join(dir, "other.env")})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = "CHANGED\tCHANGED\nREMOVED\tREMOVED\nADDED\tADDED\nADDED\tOFF\n"
+	if got != want {
+		t.Errorf("printed %q, want %q", got, want)
+	}
+}
+
+func TestCheckPorcelain(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		".env":         "PRESENT=1\nEXTRA=1\n# TOKEN=\n",
+		".env.example": "PRESENT=\nMISSING=\n# sidem:required\nTOKEN=\n",
+	})
+	checkPorcelain, checkRequireAnnotated = true, true
+	t.Cleanup(func() { checkPorcelain, checkRequireAnnotated = false, false })
+
+	got, err := captureStdout(t, func() error {
+		return runCheck(checkCmd, []string{filepath.Join(dir, ".env")})
+	})
+	if err == nil {
+		t.Error("check succeeded with a missing key")
+	}
+	const want = "MISSING\tMISSING\nEXTRA\tEXTRA\nEMPTY\tTOKEN\n"
+	if got != want {
+		t.Errorf("printed %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/taha-yassine/sidem/internal/parser"
+	"github.com/taha-yassine/sidem/internal/secrets"
+
+	"github.com/spf13/cobra"
+)
+
+var scanSecretsCmd = &cobra.Command{
+	Use:   "scan-secrets [dotenv-file]",
+	Short: "Report values that look like plaintext secrets",
+	Long: `Report the variables whose value looks like a plaintext secret: well-known
+formats (AWS keys, private keys, tokens...) and high-entropy strings.
+
+Exits with a non-zero status if any is found.`,
+	Args:          cobra.MaximumNArgs(1),
+	RunE:          runScanSecrets,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	rootCmd.AddCommand(scanSecretsCmd)
+}
+
+func runScanSecrets(cmd *cobra.Command, args []string) error {
+	filePath := filePathFromArgs(args)
+
+	// Stream the file, there's no need to hold it in memory to inspect values
+	found := 0
+	err := parser.StreamFile(filePath, func(line *parser.Line) error {
+		if line.Type != parser.LineTypeVariable {
+			return nil
+		}
+		if reason, ok := secrets.Detect(line.Value); ok {
+			found++
+			fmt.Printf("%s:%d: %s: %s\n", filePath, line.LineNumber, line.Key, reason)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if found > 0 {
+		return fmt.Errorf("found %d possible secret(s)", found)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/taha-yassine/sidem/internal/atomicfile"
+	"github.com/taha-yassine/sidem/internal/parser"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	setIgnoreCase bool
+	setFile       string
+)
+
+var setCmd = &cobra.Command{
+	Use:   "set [--file dotenv-file] KEY=VALUE...",
+	Short: "Set variables inside the managed block of a .env file",
+	Long: `Set variables inside the block delimited by
+
+  ` + parser.ManagedBlockStart + `
+  ` + parser.ManagedBlockEnd + `
+
+The block is appended to the file if it doesn't exist yet. Variables already
+in the block are updated in place, new ones are added at its end, and the
+rest of the file is left as is, except that other occurrences of a set
+variable are commented out so the managed value is the active one.
+
+The file is given with --file, since a file name may contain '=' just like
+the pairs. It defaults to $SIDEM_ENV_FILE, or .env.
+
+With --ignore-case, KEY also matches an existing key differing only in case,
+whose casing is kept.`,
+	Args:          cobra.MinimumNArgs(1),
+	RunE:          runSet,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	setCmd.Flags().BoolVar(&setIgnoreCase, "ignore-case", false, "match existing keys case-insensitively, keeping their casing")
+	setCmd.Flags().StringVarP(&setFile, "file", "f", "", "the .env file to change (default $SIDEM_ENV_FILE, or .env)")
+	rootCmd.AddCommand(setCmd)
+}
+
+func runSet(cmd *cobra.Command, args []string) error {
+	var fileArgs []string
+	if setFile != "" {
+		fileArgs = []string{setFile}
+	}
+	filePath := filePathFromArgs(fileArgs)
+
+	parsedData, err := parser.ParseFile(filePath)
+	if err != nil {
+		return err
+	}
+	for _, pair := range args {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("invalid pair %q (expected KEY=VALUE, the file is given with --file)", pair)
+		}
+		if key, _, err = parsedData.LookupKey(key, setIgnoreCase); err != nil {
+			return err
+		}
+		if err := parsedData.SetManaged(key, value); err != nil {
+			return err
+		}
+	}
+
+	content := parser.RenderLines(parsedData.Lines, parsedData)
+	if err := atomicfile.Write(filePath, []byte(content)); err != nil {
+		return fmt.Errorf("failed to write to file %s: %w", filePath, err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetFileNameWithEquals(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "conf=prod.env")
+	if err := os.WriteFile(path, []byte("A=1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	setFile = path
+	t.Cleanup(func() { setFile = "" })
+
+	if err := runSet(setCmd, []string{"B=2", "C=x=y"}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(got), "\nB=2\nC=x=y\n") {
+		t.Errorf("file is %q, want B and C set", got)
+	}
+
+	if err := runSet(setCmd, []string{path, "D=4"}); err == nil {
+		t.Error("a file name given as a pair was accepted")
+	}
+}
+
+func TestSetIgnoreCase(t *testing.T) {
+	setFile = filepath.Join(writeFiles(t, map[string]string{".env": "Path=/bin\nhome=/root\nHOME=/home\n"}), ".env")
+	setIgnoreCase = true
+	t.Cleanup(func() { setFile, setIgnoreCase = "", false })
+
+	if err := runSet(setCmd, []string{"PATH=/usr/bin"}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(setFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The value is set under the file's casing
+	if !strings.Contains(string(got), "\nPath=/usr/bin\n") || strings.Contains(string(got), "PATH") {
+		t.Errorf("file is %q, want Path set", got)
+	}
+
+	if err := runSet(setCmd, []string{"Home=/x"}); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("setting Home returned %v, want an ambiguous match error", err)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/taha-yassine/sidem/internal/parser"
+	"github.com/taha-yassine/sidem/internal/stats"
+
+	"github.com/spf13/cobra"
+)
+
+var statsJSON bool
+
+var statsCmd = &cobra.Command{
+	Use:           "stats [dotenv-file]",
+	Short:         "Print variable counts of a .env file",
+	Long:          `Print a quick health summary of a .env file: variable counts, duplicated keys, empty values and the longest value.`,
+	Args:          cobra.MaximumNArgs(1),
+	RunE:          runStats,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	statsCmd.Flags().BoolVar(&statsJSON, "json", false, "print the statistics as JSON")
+	rootCmd.AddCommand(statsCmd)
+}
+
+func runStats(cmd *cobra.Command, args []string) error {
+	parsedData, err := parser.ParseFile(filePathFromArgs(args))
+	if err != nil {
+		return err
+	}
+	s := stats.Compute(parsedData)
+
+	if statsJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(s)
+	}
+
+	fmt.Printf("Variables:   %d\n", s.Variables)
+	fmt.Printf("Active:      %d\n", s.Active)
+	fmt.Printf("Commented:   %d\n", s.Commented)
+	fmt.Printf("Keys:        %d\n", s.Groups)
+	fmt.Printf("Duplicated:  %d\n", s.Duplicates)
+	fmt.Printf("Empty:       %d\n", s.Empty)
+	if s.LongestValueKey != "" {
+		fmt.Printf("Longest:     %d (%s)\n", s.LongestValue, s.LongestValueKey)
+	} else {
+		fmt.Printf("Longest:     %d\n", s.LongestValue)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/taha-yassine/sidem/internal/stats"
+)
+
+func TestStatsJSON(t *testing.T) {
+	dir := writeFiles(t, map[string]string{".env": "A=1\n# A=22\nB=\n"})
+	statsJSON = true
+	t.Cleanup(func() { statsJSON = false })
+
+	out, err := captureStdout(t, func() error {
+		return runStats(statsCmd, []string{filepath.Join(dir, ".env")})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got stats.Stats
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("output isn't JSON: %v\n%s", err, out)
+	}
+	want := stats.Stats{Variables: 3, Active: 2, Commented: 1, Groups: 2, Duplicates: 1, Empty: 1, LongestValue: 2, LongestValueKey: "A"}
+	if got != want {
+		t.Errorf("printed %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,76 @@
+package atomicfile
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteKeepsPermissions(t *testing.T) {
+	for _, mode := range []fs.FileMode{0600, 0640, 0644} {
+		path := filepath.Join(t.TempDir(), ".env")
+		if err := os.WriteFile(path, []byte("A=1\n"), mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(path, mode); err != nil { // Not narrowed by the umask
+			t.Fatal(err)
+		}
+
+		if err := Write(path, []byte("A=2\n")); err != nil {
+			t.Fatal(err)
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := info.Mode().Perm(); got != mode {
+			t.Errorf("mode = %v, want %v", got, mode)
+		}
+		if content, _ := os.ReadFile(path); string(content) != "A=2\n" {
+			t.Errorf("content = %q", content)
+		}
+	}
+}
+
+func TestWriteNewFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := Write(path, []byte("A=1\n")); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := info.Mode().Perm(); got != defaultMode {
+		t.Errorf("mode = %v, want %v", got, defaultMode)
+	}
+}
+
+func TestWriteFollowsSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "real.env")
+	link := filepath.Join(dir, ".env")
+	if err := os.WriteFile(target, []byte("A=1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+
+	if err := Write(link, []byte("A=2\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	if info, err := os.Lstat(link); err != nil || info.Mode()&fs.ModeSymlink == 0 {
+		t.Errorf("%s is no longer a symlink", link)
+	}
+	if content, _ := os.ReadFile(target); string(content) != "A=2\n" {
+		t.Errorf("target content = %q", content)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("temporary file left behind: %v", entries)
+	}
+}
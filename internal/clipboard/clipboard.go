@@ -0,0 +1,76 @@
+package clipboard
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/atotto/clipboard"
+)
+
+// Backend selects how text is copied to the clipboard.
+type Backend string
+
+const (
+	BackendAuto   Backend = "auto"   // System clipboard, falling back to OSC 52 over SSH or when unavailable
+	BackendOSC52  Backend = "osc52"  // OSC 52 terminal escape sequence
+	BackendSystem Backend = "system" // Local clipboard utilities (xclip, pbcopy...)
+)
+
+// ParseBackend validates a clipboard backend name.
+func ParseBackend(s string) (Backend, error) {
+	switch Backend(s) {
+	case BackendAuto, BackendOSC52, BackendSystem:
+		return Backend(s), nil
+	}
+	return "", fmt.Errorf("invalid clipboard backend %q (expected auto, osc52 or system)", s)
+}
+
+// Write copies text to the clipboard using the given backend.
+func Write(backend Backend, text string) error {
+	switch backend {
+	case BackendSystem:
+		return clipboard.WriteAll(text)
+	case BackendOSC52:
+		return writeOSC52(text)
+	}
+
+	// Auto: a remote session has no access to the local clipboard
+	if isRemoteSession() || clipboard.Unsupported {
+		return writeOSC52(text)
+	}
+	if err := clipboard.WriteAll(text); err != nil {
+		return writeOSC52(text)
+	}
+	return nil
+}
+
+// isRemoteSession reports whether we are running over SSH.
+func isRemoteSession() bool {
+	return os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != ""
+}
+
+// writeOSC52 sends the OSC 52 sequence for text to the terminal.
+func writeOSC52(text string) error {
+	var out io.Writer = os.Stderr
+	if tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0); err == nil {
+		defer tty.Close()
+		out = tty
+	}
+	_, err := io.WriteString(out, OSC52Sequence(text, os.Getenv("TMUX") != ""))
+	return err
+}
+
+// OSC52Sequence returns the escape sequence asking the terminal to set its
+// clipboard to text. Inside tmux, the sequence is wrapped in a DCS passthrough
+// so that tmux forwards it to the outer terminal.
+func OSC52Sequence(text string, tmux bool) string {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	if !tmux {
+		return seq
+	}
+	// Escape characters inside the passthrough must be doubled
+	return "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+}
@@ -0,0 +1,62 @@
+package clipboard
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestOSC52Sequence(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		tmux bool
+		want string
+	}{
+		{"plain", "hello", false, "\x1b]52;c;aGVsbG8=\a"},
+		{"empty", "", false, "\x1b]52;c;\a"},
+		{"tmux passthrough", "hello", true, "\x1bPtmux;\x1b\x1b]52;c;aGVsbG8=\a\x1b\\"},
+		{"special characters", "A=\"b c\"\n", false, "\x1b]52;c;QT0iYiBjIgo=\a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := OSC52Sequence(tt.text, tt.tmux); got != tt.want {
+				t.Errorf("OSC52Sequence(%q, %v) = %q, want %q", tt.text, tt.tmux, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOSC52SequencePayload(t *testing.T) {
+	const text = "URL=postgres://u:p@host/db?x=1&y=é\n"
+	seq := OSC52Sequence(text, false)
+	payload, ok := strings.CutPrefix(seq, "\x1b]52;c;")
+	if !ok {
+		t.Fatalf("sequence %q lacks the OSC 52 prefix", seq)
+	}
+	payload, ok = strings.CutSuffix(payload, "\a")
+	if !ok {
+		t.Fatalf("sequence %q isn't terminated by BEL", seq)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(decoded) != text {
+		t.Errorf("payload decodes to %q, want %q", decoded, text)
+	}
+	if strings.ContainsAny(payload, "\x1b\a\n") {
+		t.Errorf("payload %q holds control characters", payload)
+	}
+}
+
+func TestParseBackend(t *testing.T) {
+	for _, name := range []string{"auto", "osc52", "system"} {
+		if got, err := ParseBackend(name); err != nil || string(got) != name {
+			t.Errorf("ParseBackend(%q) = %q, %v", name, got, err)
+		}
+	}
+	if _, err := ParseBackend("xclip"); err == nil {
+		t.Error("no error for an unknown backend")
+	}
+}
@@ -0,0 +1,94 @@
+package compare
+
+import (
+	"github.com/taha-yassine/sidem/internal/export"
+	"github.com/taha-yassine/sidem/internal/parser"
+)
+
+// Kind is the kind of a difference. Its values are part of the porcelain
+// output of the diff and check commands and must not change.
+type Kind string
+
+const (
+	Added   Kind = "ADDED"   // Active in the other file only
+	Removed Kind = "REMOVED" // Active in the base file only
+	Changed Kind = "CHANGED" // Active in both with different values
+	Missing Kind = "MISSING" // Declared in the template but not in the file
+	Extra   Kind = "EXTRA"   // Declared in the file but not in the template
+	Empty   Kind = "EMPTY"   // Required but empty or inactive in the file
+)
+
+// Difference is a variable that differs between two files.
+type Difference struct {
+	Kind Kind
+	Key  string
+	Old  string // Value in the base file, for Removed and Changed
+	New  string // Value in the other file, for Added and Changed
+}
+
+// Diff compares the active variables of base and other: variables removed and
+// changed come in base order, followed by added ones in other order.
+func Diff(base, other *parser.ParsedData) []Difference {
+	otherValues := make(map[string]string)
+	for _, v := range export.Active(other) {
+		otherValues[v.Key] = v.Value
+	}
+
+	var diffs []Difference
+	seen := make(map[string]bool)
+	for _, v := range export.Active(base) {
+		seen[v.Key] = true
+		value, ok := otherValues[v.Key]
+		switch {
+		case !ok:
+			diffs = append(diffs, Difference{Kind: Removed, Key: v.Key, Old: v.Value})
+		case value != v.Value:
+			diffs = append(diffs, Difference{Kind: Changed, Key: v.Key, Old: v.Value, New: value})
+		}
+	}
+	for _, v := range export.Active(other) {
+		if !seen[v.Key] {
+			diffs = append(diffs, Difference{Kind: Added, Key: v.Key, New: v.Value})
+		}
+	}
+	return diffs
+}
+
+// Check compares the keys declared in file, active or not, with those of a
+// template such as .env.example: missing keys come first, in template order,
+// followed by extra ones in file order.
+func Check(file, template *parser.ParsedData) []Difference {
+	var diffs []Difference
+	for _, key := range template.GroupOrder {
+		if _, ok := file.VariableGroups[key]; !ok {
+			diffs = append(diffs, Difference{Kind: Missing, Key: key})
+		}
+	}
+	for _, key := range file.GroupOrder {
+		if _, ok := template.VariableGroups[key]; !ok {
+			diffs = append(diffs, Difference{Kind: Extra, Key: key})
+		}
+	}
+	return diffs
+}
+
+// Unset returns the keys marked as required, in file or template, that file
+// declares without an active non-empty value, in file order. Required keys
+// missing from file are reported by Check.
+func Unset(file, template *parser.ParsedData) []Difference {
+	required := template.RequiredKeys()
+	for key := range file.RequiredKeys() {
+		required[key] = true
+	}
+
+	var diffs []Difference
+	for _, key := range file.GroupOrder {
+		if !required[key] {
+			continue
+		}
+		if line := file.VariableGroups[key].ActiveLine(); line == nil || line.Value == "" {
+			diffs = append(diffs, Difference{Kind: Empty, Key: key})
+		}
+	}
+	return diffs
+}
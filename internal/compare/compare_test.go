@@ -0,0 +1,62 @@
+package compare
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/taha-yassine/sidem/internal/parser"
+)
+
+// parse parses content as a .env file, failing the test on error.
+func parse(t *testing.T, content string) *parser.ParsedData {
+	t.Helper()
+	data, err := parser.Parse(strings.NewReader(content), ".env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestDiff(t *testing.T) {
+	base := parse(t, "A=1\nB=2\n# C=3\nD=4\nE=same\n")
+	other := parse(t, "E=same\nF=6\n# A=1\nB=20\nC=3\nD=4\n")
+	want := []Difference{
+		{Kind: Removed, Key: "A", Old: "1"},
+		{Kind: Changed, Key: "B", Old: "2", New: "20"},
+		{Kind: Added, Key: "F", New: "6"},
+		{Kind: Added, Key: "C", New: "3"},
+	}
+	if got := Diff(base, other); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff =\n%+v\nwant\n%+v", got, want)
+	}
+	if got := Diff(base, base); got != nil {
+		t.Errorf("Diff of a file with itself = %+v, want none", got)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	file := parse(t, "EXTRA=1\nA=1\n# B=2\n")
+	template := parse(t, "A=\nB=\nC=\nD=\n")
+	want := []Difference{
+		{Kind: Missing, Key: "C"},
+		{Kind: Missing, Key: "D"},
+		{Kind: Extra, Key: "EXTRA"},
+	}
+	if got := Check(file, template); !reflect.DeepEqual(got, want) {
+		t.Errorf("Check =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestUnset(t *testing.T) {
+	file := parse(t, "# sidem:required\nSET=1\n# sidem:required\nEMPTY=\nREQUIRED_BY_TEMPLATE=\n# sidem:required\n# COMMENTED=1\nOPTIONAL=\n")
+	template := parse(t, "# sidem:required\nREQUIRED_BY_TEMPLATE=\n# sidem:required\nNOT_IN_FILE=\n")
+	want := []Difference{
+		{Kind: Empty, Key: "EMPTY"},
+		{Kind: Empty, Key: "REQUIRED_BY_TEMPLATE"},
+		{Kind: Empty, Key: "COMMENTED"},
+	}
+	if got := Unset(file, template); !reflect.DeepEqual(got, want) {
+		t.Errorf("Unset =\n%+v\nwant\n%+v", got, want)
+	}
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Config holds the user preferences read from the configuration file.
+type Config struct {
+	Snippets      map[string]string `json:"snippets"`       // Value templates by name, e.g. "pgurl": "postgres://${USER}@${HOST}"
+	StatusTimeout *Duration         `json:"status_timeout"` // How long transient status messages stay, nil if unset
+
+	// ReferenceTemplate is what 'R' copies for secret variables instead of
+	// their value, with {key} replaced by the variable name, e.g. "vault:app#{key}".
+	ReferenceTemplate string `json:"reference_template"`
+
+	// HeaderAccents tint the header according to the file name, the first
+	// matching rule winning. Nil when unset, DefaultHeaderAccents then apply;
+	// an empty list disables tinting.
+	HeaderAccents []HeaderAccent `json:"header_accents"`
+
+	// SecretKeys are the parts of key names (case-insensitive) whose values are
+	// masked by --mask-secrets, e.g. ["KEY", "DSN"]. Nil when unset, the
+	// built-in list (KEY, SECRET, TOKEN, PASSWORD...) then applies.
+	SecretKeys []string `json:"secret_keys"`
+
+	Theme       string `json:"theme"`        // "default" or "nature", empty if unset
+	MaskSecrets *bool  `json:"mask_secrets"` // Same as --mask-secrets, nil if unset
+}
+
+// DefaultReferenceTemplate copies a ${KEY} reference to the variable.
+const DefaultReferenceTemplate = "${{key}}"
+
+// HeaderAccent tints the header of files whose name contains Match
+// (case-insensitive) with Color, e.g. red for production files.
+type HeaderAccent struct {
+	Match string `json:"match"`
+	Color string `json:"color"` // Hex color such as "#ff5555", or an ANSI color number
+}
+
+// DefaultHeaderAccents warn about production files in red and staging ones in
+// orange, and mark development files in green.
+var DefaultHeaderAccents = []HeaderAccent{
+	{Match: "prod", Color: "#ff5555"},
+	{Match: "staging", Color: "#ffb86c"},
+	{Match: "dev", Color: "#50fa7b"},
+	{Match: "local", Color: "#50fa7b"},
+}
+
+// Duration is a time.Duration written as a string in the configuration file, e.g. "3s".
+type Duration time.Duration
+
+// UnmarshalJSON parses a duration string such as "500ms" or "3s".
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"3s\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// DefaultPath returns the default location of the configuration file,
+// e.g. ~/.config/sidem/config.json on Linux.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %w", err)
+	}
+	return filepath.Join(dir, "sidem", "config.json"), nil
+}
+
+// Load reads the configuration file at path.
+// A missing file is not an error and yields an empty configuration.
+func Load(path string) (*Config, error) {
+	cfg := &Config{}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error reading config %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(content, cfg); err != nil {
+		return nil, fmt.Errorf("error parsing config %s: %w", path, err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// writeConfig writes content to a configuration file in a temporary directory.
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	cfg, err := Load(writeConfig(t, `{
+  "theme": "nature",
+  "mask_secrets": false,
+  "status_timeout": "500ms",
+  "snippets": {"pgurl": "postgres://${USER}@${HOST}"},
+  "header_accents": [{"match": "qa", "color": "3"}]
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Theme != "nature" || cfg.MaskSecrets == nil || *cfg.MaskSecrets {
+		t.Errorf("theme %q, mask_secrets %v", cfg.Theme, cfg.MaskSecrets)
+	}
+	if cfg.StatusTimeout == nil || time.Duration(*cfg.StatusTimeout) != 500*time.Millisecond {
+		t.Errorf("status_timeout %v, want 500ms", cfg.StatusTimeout)
+	}
+	if cfg.Snippets["pgurl"] != "postgres://${USER}@${HOST}" {
+		t.Errorf("snippets %v", cfg.Snippets)
+	}
+	if want := []HeaderAccent{{Match: "qa", Color: "3"}}; !reflect.DeepEqual(cfg.HeaderAccents, want) {
+		t.Errorf("header_accents %v, want %v", cfg.HeaderAccents, want)
+	}
+}
+
+func TestLoadUnset(t *testing.T) {
+	cfg, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil || !reflect.DeepEqual(cfg, &Config{}) {
+		t.Errorf("missing file loaded as %+v, %v, want an empty configuration", cfg, err)
+	}
+
+	// An empty list disables header tinting rather than falling back to the defaults
+	cfg, err = Load(writeConfig(t, `{"header_accents": []}`))
+	if err != nil || cfg.HeaderAccents == nil || len(cfg.HeaderAccents) != 0 {
+		t.Errorf("empty header_accents loaded as %#v, %v", cfg.HeaderAccents, err)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	for _, content := range []string{`{"status_timeout": 3}`, `{"status_timeout": "soon"}`, `{`} {
+		if _, err := Load(writeConfig(t, content)); err == nil {
+			t.Errorf("%s loaded without error", content)
+		}
+	}
+}
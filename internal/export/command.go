@@ -0,0 +1,66 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// commandSubstRegex matches $(command) substitutions in a value.
+var commandSubstRegex = regexp.MustCompile(`\$\(([^()]*)\)`)
+
+// SubstituteCommands returns vars with every $(command) substitution replaced
+// by the standard output of the command, without its trailing newlines.
+// Commands are run with sh and killed after timeout. This executes arbitrary
+// code from the file and must only be done when explicitly requested.
+func SubstituteCommands(vars []Variable, timeout time.Duration) ([]Variable, error) {
+	result := make([]Variable, len(vars))
+	for i, v := range vars {
+		var substErr error
+		value := commandSubstRegex.ReplaceAllStringFunc(v.Value, func(match string) string {
+			if substErr != nil {
+				return match
+			}
+			command := commandSubstRegex.FindStringSubmatch(match)[1]
+			output, err := runCommand(command, timeout)
+			if err != nil {
+				substErr = fmt.Errorf("%s: command %q: %w", v.Key, command, err)
+				return match
+			}
+			return output
+		})
+		if substErr != nil {
+			return nil, substErr
+		}
+		result[i] = Variable{Key: v.Key, Value: value}
+	}
+	return result, nil
+}
+
+// runCommand runs command with sh and returns its standard output.
+func runCommand(command string, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = time.Second // Don't hang on children keeping the pipes open
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("timed out after %s", timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return strings.TrimRight(stdout.String(), "\n"), nil
+}
@@ -0,0 +1,50 @@
+package export
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSubstituteCommands(t *testing.T) {
+	vars := []Variable{
+		{Key: "GREETING", Value: "$(echo hello) world"},
+		{Key: "TWICE", Value: "$(printf a)-$(printf 'b\n\n')"},
+		{Key: "PLAIN", Value: "${HOME} $PATH"},
+	}
+	got, err := SubstituteCommands(vars, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Variable{
+		{Key: "GREETING", Value: "hello world"},
+		{Key: "TWICE", Value: "a-b"},
+		{Key: "PLAIN", Value: "${HOME} $PATH"},
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("variable %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+	if vars[0].Value != "$(echo hello) world" {
+		t.Errorf("substituting changed the input to %q", vars[0].Value)
+	}
+}
+
+func TestSubstituteCommandsTimeout(t *testing.T) {
+	start := time.Now()
+	_, err := SubstituteCommands([]Variable{{Key: "SLOW", Value: "$(sleep 10)"}}, 100*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "timed out") || !strings.Contains(err.Error(), "SLOW") {
+		t.Errorf("error is %v, want SLOW timing out", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("the command was killed after %s", elapsed)
+	}
+}
+
+func TestSubstituteCommandsFailure(t *testing.T) {
+	_, err := SubstituteCommands([]Variable{{Key: "BAD", Value: "$(echo oops >&2; exit 3)"}}, 5*time.Second)
+	if err == nil || !strings.Contains(err.Error(), "oops") {
+		t.Errorf("error is %v, want the command's standard error", err)
+	}
+}
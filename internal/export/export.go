@@ -0,0 +1,96 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/taha-yassine/sidem/internal/parser"
+)
+
+// Output formats supported by Write.
+const (
+	FormatEnv  = "env"  // KEY=value lines
+	FormatJSON = "json" // {"KEY": "value", ...}
+)
+
+// Variable is an active variable: the selected value of a selected group.
+type Variable struct {
+	Key   string
+	Value string
+}
+
+// Active returns the active variables of pd, in display order.
+// Groups that are not selected are left out.
+func Active(pd *parser.ParsedData) []Variable {
+	vars := []Variable{}
+	for _, key := range pd.GroupOrder {
+		if line := pd.VariableGroups[key].ActiveLine(); line != nil {
+			vars = append(vars, Variable{Key: key, Value: line.Value})
+		}
+	}
+	return vars
+}
+
+// Diff returns the variables of vars whose value differs from, or is absent in, base.
+func Diff(vars, base []Variable) []Variable {
+	baseValues := make(map[string]string, len(base))
+	for _, v := range base {
+		baseValues[v.Key] = v.Value
+	}
+
+	diff := []Variable{}
+	for _, v := range vars {
+		if baseValue, ok := baseValues[v.Key]; !ok || baseValue != v.Value {
+			diff = append(diff, v)
+		}
+	}
+	return diff
+}
+
+// Write writes vars to w in the given format.
+func Write(w io.Writer, format string, vars []Variable) error {
+	switch format {
+	case FormatEnv:
+		for _, v := range vars {
+			if _, err := fmt.Fprintf(w, "%s=%s\n", v.Key, parser.QuoteValue(v.Value)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case FormatJSON:
+		return writeJSON(w, vars)
+	}
+	return fmt.Errorf("unknown format %q (expected %s or %s)", format, FormatEnv, FormatJSON)
+}
+
+// writeJSON writes vars as a JSON object, keeping their order.
+func writeJSON(w io.Writer, vars []Variable) error {
+	var builder strings.Builder
+	builder.WriteString("{")
+	for i, v := range vars {
+		key, err := json.Marshal(v.Key)
+		if err != nil {
+			return err
+		}
+		value, err := json.Marshal(v.Value)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			builder.WriteString(",")
+		}
+		builder.WriteString("\n  ")
+		builder.Write(key)
+		builder.WriteString(": ")
+		builder.Write(value)
+	}
+	if len(vars) > 0 {
+		builder.WriteString("\n")
+	}
+	builder.WriteString("}\n")
+
+	_, err := io.WriteString(w, builder.String())
+	return err
+}
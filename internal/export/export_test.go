@@ -0,0 +1,49 @@
+package export
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/taha-yassine/sidem/internal/parser"
+)
+
+func TestDiff(t *testing.T) {
+	vars := []Variable{{"SAME", "1"}, {"CHANGED", "new"}, {"ADDED", "x"}, {"EMPTIED", ""}}
+	base := []Variable{{"SAME", "1"}, {"CHANGED", "old"}, {"REMOVED", "y"}, {"EMPTIED", "z"}}
+	want := []Variable{{"CHANGED", "new"}, {"ADDED", "x"}, {"EMPTIED", ""}}
+	if got := Diff(vars, base); !slices.Equal(got, want) {
+		t.Errorf("Diff = %v, want %v", got, want)
+	}
+	if got := Diff(vars, vars); len(got) != 0 {
+		t.Errorf("Diff against itself = %v, want none", got)
+	}
+}
+
+func TestDiffOfActiveVariables(t *testing.T) {
+	parse := func(content string) []Variable {
+		data, err := parser.Parse(strings.NewReader(content), ".env")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return Active(data)
+	}
+	// Only the active values count: a commented-out base value is absent
+	vars := parse("HOST=db\n# HOST=localhost\nPORT=5432\nDEBUG=true\n")
+	base := parse("# HOST=db\nHOST=localhost\nPORT=5432\n# DEBUG=true\n")
+
+	var out strings.Builder
+	if err := Write(&out, FormatEnv, Diff(vars, base)); err != nil {
+		t.Fatal(err)
+	}
+	if want := "HOST=db\nDEBUG=true\n"; out.String() != want {
+		t.Errorf("env export %q, want %q", out.String(), want)
+	}
+	out.Reset()
+	if err := Write(&out, FormatJSON, Diff(vars, base)); err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n  \"HOST\": \"db\",\n  \"DEBUG\": \"true\"\n}\n"; out.String() != want {
+		t.Errorf("JSON export %q, want %q", out.String(), want)
+	}
+}
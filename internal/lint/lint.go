@@ -0,0 +1,66 @@
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/taha-yassine/sidem/internal/parser"
+)
+
+// Issue is a structural problem found in a parsed .env file.
+type Issue struct {
+	Line    int // 1-based line number in the file
+	Message string
+}
+
+// Check reports the structural issues of a file that parses: keys active more
+// than once, assignments whose key is invalid and which are therefore taken
+// for comments, keys differing only by case and trailing whitespace. Issues
+// come in line order.
+func Check(data *parser.ParsedData) []Issue {
+	var issues []Issue
+
+	for _, key := range data.GroupOrder {
+		active := 0
+		for _, line := range data.VariableGroups[key].Lines {
+			if line.IsCommentedOut {
+				continue
+			}
+			active++
+			if active > 1 {
+				issues = append(issues, Issue{line.LineNumber, fmt.Sprintf("%s is already active above, only the first value is used", key)})
+			}
+		}
+	}
+
+	firstByFold := make(map[string]string)
+	for _, key := range data.GroupOrder {
+		folded := strings.ToUpper(key)
+		first, ok := firstByFold[folded]
+		if !ok {
+			firstByFold[folded] = key
+			continue
+		}
+		line := data.VariableGroups[key].Lines[0]
+		issues = append(issues, Issue{line.LineNumber, fmt.Sprintf("%s differs from %s only by case", key, first)})
+	}
+
+	for _, line := range data.Lines {
+		if isInvalidAssignment(line) {
+			issues = append(issues, Issue{line.LineNumber, "invalid key, the line is treated as a comment"})
+		}
+		if content := line.OriginalContent; content != strings.TrimRight(content, " \t") {
+			issues = append(issues, Issue{line.LineNumber, "trailing whitespace"})
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Line < issues[j].Line })
+	return issues
+}
+
+// isInvalidAssignment reports whether a line kept as a comment is actually
+// meant as an assignment, e.g. "MY-KEY=value": it is invalid yet holds a '='.
+func isInvalidAssignment(line *parser.Line) bool {
+	return line.Invalid && strings.Contains(line.OriginalContent, "=")
+}
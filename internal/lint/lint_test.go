@@ -0,0 +1,38 @@
+package lint
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/taha-yassine/sidem/internal/parser"
+)
+
+func check(t *testing.T, content string) []Issue {
+	t.Helper()
+	data, err := parser.Parse(strings.NewReader(content), ".env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Check(data)
+}
+
+func TestCheckClean(t *testing.T) {
+	const content = "#!/usr/bin/env sh\n# Database\nDB_HOST=localhost\n# DB_HOST=remote\n\nexport PORT=\"5432\" # default\n"
+	if issues := check(t, content); len(issues) != 0 {
+		t.Errorf("issues found in a clean file: %v", issues)
+	}
+}
+
+func TestCheckIssues(t *testing.T) {
+	const content = "A=1\nMY-KEY=x\nA=2\nb=3 \n# see a=b\nB=4\n" // Line 5 is a plain comment
+	want := []Issue{
+		{2, "invalid key, the line is treated as a comment"},
+		{3, "A is already active above, only the first value is used"},
+		{4, "trailing whitespace"},
+		{6, "B differs from b only by case"},
+	}
+	if got := check(t, content); !slices.Equal(got, want) {
+		t.Errorf("issues are %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,134 @@
+package parser
+
+import (
+	"slices"
+	"strings"
+)
+
+// AnnotationPrefix starts the provenance comment written above changed variables.
+const AnnotationPrefix = "last-changed:"
+
+// RequiredAnnotation is the comment marking a variable as required, in the
+// comment block directly above its first occurrence.
+const RequiredAnnotation = "sidem:required"
+
+// Annotate sets the provenance comment of a variable to
+// "# last-changed: <text>" on the line just above its first occurrence.
+// An existing annotation there is updated instead of adding another one.
+func (pd *ParsedData) Annotate(key, text string) {
+	group, ok := pd.VariableGroups[key]
+	if !ok || len(group.Lines) == 0 {
+		return
+	}
+	index := pd.lineIndex(group.Lines[0])
+	if index == -1 {
+		return
+	}
+	content := pd.commentMarker() + " " + AnnotationPrefix + " " + text
+
+	if index > 0 {
+		previous := pd.Lines[index-1]
+		if previous.Type == LineTypeComment && isAnnotation(previous.OriginalContent) {
+			previous.OriginalContent = content
+			return
+		}
+	}
+	pd.insertLine(index, &Line{
+		OriginalContent: content,
+		Type:            LineTypeComment,
+		SourceFile:      group.Lines[0].SourceFile,
+	})
+}
+
+// isAnnotation reports whether a comment line is a provenance annotation.
+func isAnnotation(content string) bool {
+	trimmed := strings.TrimSpace(content)
+	if trimmed == "" {
+		return false
+	}
+	return strings.HasPrefix(strings.TrimSpace(trimmed[1:]), AnnotationPrefix)
+}
+
+// Description returns the comment block directly above the first occurrence
+// of key, one entry per line without the comment marker. Provenance
+// annotations are left out, and so is the front matter.
+func (pd *ParsedData) Description(key string) []string {
+	group, ok := pd.VariableGroups[key]
+	if !ok || len(group.Lines) == 0 {
+		return nil
+	}
+	index := pd.lineIndex(group.Lines[0])
+
+	var frontMatterEnd *Line
+	if fm := pd.FrontMatter(); fm != nil {
+		frontMatterEnd = fm.End
+	}
+
+	var description []string
+	for i := index - 1; i >= 0 && pd.Lines[i].Type == LineTypeComment && pd.Lines[i] != frontMatterEnd; i-- {
+		if pd.isDirective(pd.Lines[i]) {
+			break // Shell directives are not part of any description
+		}
+		content := strings.TrimSpace(pd.Lines[i].OriginalContent)
+		if isAnnotation(content) || commentText(pd.Lines[i]) == RequiredAnnotation || content == ManagedBlockStart || content == ManagedBlockEnd {
+			continue
+		}
+		description = append([]string{strings.TrimSpace(content[1:])}, description...)
+	}
+	return description
+}
+
+// RequiredKeys returns the keys marked as required with a "# sidem:required"
+// comment above their first occurrence.
+func (pd *ParsedData) RequiredKeys() map[string]bool {
+	required := make(map[string]bool)
+	pending := false // Required annotation in the current comment block
+	for _, line := range pd.Lines {
+		switch line.Type {
+		case LineTypeComment:
+			if commentText(line) == RequiredAnnotation {
+				pending = true
+			}
+		case LineTypeVariable:
+			if group, ok := pd.VariableGroups[line.Key]; pending && ok && group.Lines[0] == line {
+				required[line.Key] = true
+			}
+			pending = false
+		default:
+			pending = false
+		}
+	}
+	return required
+}
+
+// SetRequired adds or removes the "# sidem:required" comment above the first
+// occurrence of key.
+func (pd *ParsedData) SetRequired(key string, required bool) {
+	group, ok := pd.VariableGroups[key]
+	if !ok || len(group.Lines) == 0 {
+		return
+	}
+	index := pd.lineIndex(group.Lines[0])
+	if index == -1 {
+		return
+	}
+
+	annotation := -1
+	for i := index - 1; i >= 0 && pd.Lines[i].Type == LineTypeComment; i-- {
+		if commentText(pd.Lines[i]) == RequiredAnnotation {
+			annotation = i
+			break
+		}
+	}
+
+	switch {
+	case required && annotation == -1:
+		pd.insertLine(index, &Line{
+			OriginalContent: pd.commentMarker() + " " + RequiredAnnotation,
+			Type:            LineTypeComment,
+			SourceFile:      group.Lines[0].SourceFile,
+		})
+	case !required && annotation != -1:
+		pd.Lines = slices.Delete(pd.Lines, annotation, annotation+1)
+	}
+}
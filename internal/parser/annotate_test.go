@@ -0,0 +1,37 @@
+package parser
+
+import "testing"
+
+func TestAnnotate(t *testing.T) {
+	data := parse(t, "# Database\nDB_HOST=localhost\n# DB_HOST=remote\n")
+	data.Annotate("DB_HOST", "first")
+	data.Annotate("DB_HOST", "second")
+
+	want := "# Database\n# last-changed: second\nDB_HOST=localhost\n# DB_HOST=remote\n"
+	if got := render(data); got != want {
+		t.Errorf("rendered %q, want %q", got, want)
+	}
+}
+
+func TestSetRequired(t *testing.T) {
+	const content = "# Database\nDB_HOST=localhost\n# DB_HOST=remote\nPORT=5432\n"
+	data := parse(t, content)
+
+	data.SetRequired("DB_HOST", true)
+	data.SetRequired("DB_HOST", true)
+	want := "# Database\n# sidem:required\nDB_HOST=localhost\n# DB_HOST=remote\nPORT=5432\n"
+	if got := render(data); got != want {
+		t.Errorf("rendered %q, want %q", got, want)
+	}
+	if required := parse(t, want).RequiredKeys(); !required["DB_HOST"] || required["PORT"] {
+		t.Errorf("required keys read back as %v, want DB_HOST only", required)
+	}
+	if got := data.Description("DB_HOST"); len(got) != 1 || got[0] != "Database" {
+		t.Errorf("description is %q, want the annotation left out", got)
+	}
+
+	data.SetRequired("DB_HOST", false)
+	if got := render(data); got != content {
+		t.Errorf("rendered %q after marking optional, want the original", got)
+	}
+}
@@ -0,0 +1,91 @@
+package parser
+
+import "strings"
+
+// FrontMatterDelimiter opens and closes the front matter block, as a comment line.
+const FrontMatterDelimiter = "---"
+
+// FrontMatter is a block of "key: value" comment lines at the very top of a
+// file, between two "# ---" lines, holding sidem settings for that file:
+//
+//	# ---
+//	# theme: nature
+//	# pinned: [DATABASE_URL, PORT]
+//	# ---
+//
+// Values are YAML-like scalars or flow lists, optionally followed by a " #"
+// comment. The block is written back as is.
+type FrontMatter struct {
+	Start, End *Line             // Delimiter lines
+	Settings   map[string]string // Raw values by setting name
+}
+
+// FrontMatter returns the front matter block of the file, or nil if the first
+// line doesn't open one or it is never closed.
+func (pd *ParsedData) FrontMatter() *FrontMatter {
+	if len(pd.Lines) == 0 || commentText(pd.Lines[0]) != FrontMatterDelimiter {
+		return nil
+	}
+	fm := &FrontMatter{Start: pd.Lines[0], Settings: make(map[string]string)}
+	for _, line := range pd.Lines[1:] {
+		if line.Type != LineTypeComment {
+			return nil
+		}
+		text := commentText(line)
+		if text == FrontMatterDelimiter {
+			fm.End = line
+			return fm
+		}
+		if name, value, ok := strings.Cut(text, ":"); ok {
+			value, _, _ = strings.Cut(value, " #") // YAML comment
+			fm.Settings[strings.TrimSpace(name)] = unquoteScalar(strings.TrimSpace(value))
+		}
+	}
+	return nil
+}
+
+// Bool returns the boolean value of a setting, false if unset or not a boolean.
+func (fm *FrontMatter) Bool(name string) bool {
+	switch strings.ToLower(fm.Settings[name]) {
+	case "true", "yes", "on":
+		return true
+	}
+	return false
+}
+
+// List returns the items of a setting written as a flow list ("[A, B]"),
+// or the single item of a scalar one.
+func (fm *FrontMatter) List(name string) []string {
+	value := fm.Settings[name]
+	if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
+		value = value[1 : len(value)-1]
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = unquoteScalar(strings.TrimSpace(item)); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// commentText returns the text of a comment line without its marker, trimmed,
+// or "" for other lines.
+func commentText(line *Line) string {
+	if line.Type != LineTypeComment {
+		return ""
+	}
+	trimmed := strings.TrimSpace(line.OriginalContent)
+	if trimmed == "" {
+		return ""
+	}
+	return strings.TrimSpace(trimmed[1:])
+}
+
+// unquoteScalar removes the quotes around a YAML-like scalar.
+func unquoteScalar(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
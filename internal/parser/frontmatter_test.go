@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"slices"
+	"testing"
+)
+
+const frontMatterBlock = "# ---\n#theme:  nature   # for prod\n# mask_secrets: yes\n# mask: ['DB_URL', TOKEN]\n# pinned: PORT\n#   ---  \n"
+
+func TestFrontMatter(t *testing.T) {
+	data := parse(t, frontMatterBlock+"# Database\nDB_URL = postgres://db\nPORT=5432\n\n")
+	fm := data.FrontMatter()
+	if fm == nil {
+		t.Fatal("no front matter found")
+	}
+	if fm.Start != data.Lines[0] || fm.End != data.Lines[5] {
+		t.Errorf("block spans lines %d to %d, want 1 to 6", fm.Start.LineNumber, fm.End.LineNumber)
+	}
+	if got := fm.Settings["theme"]; got != "nature" {
+		t.Errorf("theme = %q, want nature", got)
+	}
+	if !fm.Bool("mask_secrets") || fm.Bool("theme") || fm.Bool("missing") {
+		t.Error("booleans read wrong")
+	}
+	if got := fm.List("mask"); !slices.Equal(got, []string{"DB_URL", "TOKEN"}) {
+		t.Errorf("mask = %q", got)
+	}
+	if got := fm.List("pinned"); !slices.Equal(got, []string{"PORT"}) {
+		t.Errorf("pinned = %q", got)
+	}
+
+	// The block isn't the description of the first variable
+	if got := data.Description("DB_URL"); !slices.Equal(got, []string{"Database"}) {
+		t.Errorf("description of DB_URL = %q", got)
+	}
+
+	// It is written back byte for byte, also when the file is tidied
+	data.NormalizeSpacing(SpacingCompact)
+	data.TrimTrailingBlankLines()
+	if got, want := render(data), frontMatterBlock+"# Database\nDB_URL=postgres://db\nPORT=5432\n"; got != want {
+		t.Errorf("rendered %q, want %q", got, want)
+	}
+}
+
+func TestFrontMatterMissing(t *testing.T) {
+	for _, content := range []string{
+		"A=1\n",
+		"# Comment\n# ---\n# theme: nature\n# ---\n", // Not at the top
+		"# ---\n# theme: nature\nA=1\n# ---\n",       // Never closed before a variable
+	} {
+		if fm := parse(t, content).FrontMatter(); fm != nil {
+			t.Errorf("front matter found in %q: %v", content, fm.Settings)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package parser
+
+import "fmt"
+
+// Markers delimiting the block of variables managed by sidem's programmatic
+// operations (e.g. `sidem set`). Lines outside the block are never touched by them.
+const (
+	ManagedBlockStart = "# >>> sidem managed >>>"
+	ManagedBlockEnd   = "# <<< sidem managed <<<"
+)
+
+// ManagedBlock holds the marker lines of a managed block.
+type ManagedBlock struct {
+	Start *Line
+	End   *Line
+}
+
+// trackManagedMarker records the managed block boundaries while parsing.
+// Only the first complete block is tracked; an unterminated start marker is ignored.
+func trackManagedMarker(pd *ParsedData, line *Line, trimmed string, start **Line) {
+	if pd.Managed != nil {
+		return
+	}
+	switch {
+	case trimmed == ManagedBlockStart:
+		*start = line
+	case trimmed == ManagedBlockEnd && *start != nil:
+		pd.Managed = &ManagedBlock{Start: *start, End: line}
+	}
+}
+
+// SetManaged sets key to value inside the managed block, creating the block at
+// the end of the file if needed. An occurrence already inside the block is
+// updated in place, otherwise a new line is added just before the end marker.
+// The managed line becomes the group's active value.
+func (pd *ParsedData) SetManaged(key, value string) error {
+	if !IsValidKey(key) {
+		return fmt.Errorf("invalid key %q", key)
+	}
+	start, end := pd.ensureManagedBlock()
+
+	group, ok := pd.VariableGroups[key]
+	if ok {
+		for i, line := range group.Lines {
+			if idx := pd.lineIndex(line); idx > start && idx < end {
+				line.SetValue(value)
+				group.IsSelected = true
+				group.SelectedLineIdx = i
+				return nil
+			}
+		}
+	} else {
+		group = &VariableGroup{Key: key, SelectedLineIdx: -1}
+		pd.VariableGroups[key] = group
+		pd.GroupOrder = append(pd.GroupOrder, key)
+	}
+
+	prefix := group.exportPrefix()
+	line := &Line{
+		OriginalContent: prefix + key + "=" + QuoteValue(value),
+		Type:            LineTypeVariable,
+		SourceFile:      pd.Managed.Start.SourceFile,
+		Key:             key,
+		Value:           value,
+		Exported:        prefix != "",
+	}
+	pd.insertLine(end, line)
+	index := pd.lineIndex(line)
+
+	// Keep the group's lines in file order
+	pos := len(group.Lines)
+	for i, groupLine := range group.Lines {
+		if pd.lineIndex(groupLine) > index {
+			pos = i
+			break
+		}
+	}
+	group.Lines = append(group.Lines[:pos], append([]*Line{line}, group.Lines[pos:]...)...)
+	group.IsSelected = true
+	group.SelectedLineIdx = pos
+	return nil
+}
+
+// ensureManagedBlock appends an empty managed block if the file has none,
+// and returns the indexes of its start and end markers in Lines.
+func (pd *ParsedData) ensureManagedBlock() (int, int) {
+	if pd.Managed == nil {
+		source := ""
+		if len(pd.Lines) > 0 {
+			last := pd.Lines[len(pd.Lines)-1]
+			source = last.SourceFile
+			if last.Type != LineTypeBlank {
+				pd.Lines = append(pd.Lines, &Line{Type: LineTypeBlank, SourceFile: source})
+			}
+		}
+		pd.Managed = &ManagedBlock{
+			Start: &Line{OriginalContent: ManagedBlockStart, Type: LineTypeComment, SourceFile: source},
+			End:   &Line{OriginalContent: ManagedBlockEnd, Type: LineTypeComment, SourceFile: source},
+		}
+		pd.Lines = append(pd.Lines, pd.Managed.Start, pd.Managed.End)
+	}
+	return pd.lineIndex(pd.Managed.Start), pd.lineIndex(pd.Managed.End)
+}
+
+// lineIndex returns the index of line in Lines, or -1.
+func (pd *ParsedData) lineIndex(line *Line) int {
+	for i, l := range pd.Lines {
+		if l == line {
+			return i
+		}
+	}
+	return -1
+}
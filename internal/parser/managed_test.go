@@ -0,0 +1,37 @@
+package parser
+
+import "testing"
+
+func TestSetManaged(t *testing.T) {
+	data := parse(t, "# Manual\nA=1 # keep\nB=2\n")
+
+	for _, set := range [][2]string{{"C", "3"}, {"B", "managed"}, {"C", "4"}} {
+		if err := data.SetManaged(set[0], set[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := "# Manual\nA=1 # keep\n# B=2\n\n" + ManagedBlockStart + "\nC=4\nB=managed\n" + ManagedBlockEnd + "\n"
+	if got := render(data); got != want {
+		t.Errorf("rendered %q, want %q", got, want)
+	}
+
+	// The block is found again once parsed, and edits outside it survive
+	again := parse(t, "A=edited\n"+want[len("# Manual\nA=1 # keep\n"):])
+	if err := again.SetManaged("D", "5"); err != nil {
+		t.Fatal(err)
+	}
+	want = "A=edited\n# B=2\n\n" + ManagedBlockStart + "\nC=4\nB=managed\nD=5\n" + ManagedBlockEnd + "\n"
+	if got := render(again); got != want {
+		t.Errorf("rendered %q, want %q", got, want)
+	}
+}
+
+func TestSetManagedInvalidKey(t *testing.T) {
+	data := parse(t, "A=1\n")
+	if err := data.SetManaged("1A", "x"); err == nil {
+		t.Error("SetManaged accepted an invalid key")
+	}
+	if got := render(data); got != "A=1\n" {
+		t.Errorf("rendered %q after a failed set", got)
+	}
+}
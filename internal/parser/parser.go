@@ -2,11 +2,16 @@ package parser
 
 import (
 	"bufio"
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"unicode/utf8"
 )
 
 // LineType defines the type of a line in the .env file.
@@ -23,11 +28,18 @@ type Line struct {
 	OriginalContent string   // The raw line content as read from the file.
 	Type            LineType // Type of the line (Blank, Comment, Variable).
 	LineNumber      int      // Original 1-based line number.
+	SourceFile      string   // Path of the file the line was read from.
+	Invalid         bool     // True for a line kept as a comment without being one, e.g. "1FOO=bar".
 
 	// Fields specific to Variable lines
-	Key            string // Variable name (e.g., "DATABASE_URL").
-	Value          string // Variable value (e.g., "postgres://...").
-	IsCommentedOut bool   // True if the variable line starts with '#'.
+	Key               string // Variable name (e.g., "DATABASE_URL").
+	Value             string // Variable value (e.g., "postgres://..."), escape sequences processed.
+	RawValue          string // Value as written in the file, between the quotes and with its escape sequences.
+	Exported          bool   // True if the variable is prefixed with 'export', kept when commenting it out.
+	IsCommentedOut    bool   // True if the variable line starts with '#'.
+	Comment           string // Inline comment text after the value, without the '#' (e.g. "prod").
+	SpaceAroundEquals bool   // True if the '=' is surrounded by whitespace (e.g. "KEY = value").
+	QuoteType         rune   // Quotes around the value in the file: 0 (unquoted), '\'' or '"'.
 }
 
 // VariableGroup holds all occurrences of a variable with the same key.
@@ -39,153 +51,925 @@ type VariableGroup struct {
 	SelectedLineIdx int     // Index within Lines pointing to the currently selected value. Holds last selection if IsSelected is false.
 }
 
+// exportPrefix returns "export " if the group's lines use the 'export' keyword,
+// to write new occurrences the same way. It is nil-safe.
+func (g *VariableGroup) exportPrefix() string {
+	if g == nil {
+		return ""
+	}
+	for _, line := range g.Lines {
+		if line.Exported {
+			return "export "
+		}
+	}
+	return ""
+}
+
+// ActiveLine returns the line holding the group's active value,
+// or nil if the group is not selected.
+func (g *VariableGroup) ActiveLine() *Line {
+	if !g.IsSelected || g.SelectedLineIdx < 0 || g.SelectedLineIdx >= len(g.Lines) {
+		return nil
+	}
+	return g.Lines[g.SelectedLineIdx]
+}
+
 // ParsedData holds the complete parsed information from the .env file.
 type ParsedData struct {
 	Lines          []*Line                   // All lines in their original order.
 	VariableGroups map[string]*VariableGroup // Variables grouped by key.
 	GroupOrder     []string                  // Order in which variable groups should be displayed.
+	Managed        *ManagedBlock             // Boundaries of the managed block, nil if the file has none.
+	CommentMarker  string                    // Marker used to comment out variables, "#" unless parsed with another one.
+	Conflicts      []string                  // Keys uncommented on several lines, of which only the first is used (see DetectConflicts).
+	KeepFragment   bool                      // Parsed with Options.KeepFragment, unquoted values have no inline comment.
 }
 
 // variableRegex matches potential variable lines (commented or uncommented).
 // It captures:
-// 1: Optional comment marker (#)
+// 1: Optional comment marker (# or ;)
 // 2: Key (either 'quoted' or unquoted)
 // 3: The rest of the line after the '=' (value + optional inline comment)
-// It handles optional 'export' prefix and spaces around '=', '#'.
-var variableRegex = regexp.MustCompile(`^\s*(#)?\s*(?:export\s+)?('?[A-Za-z_][A-Za-z0-9_]*'?)\s*=\s*(.*)$`)
+// It handles optional 'export' prefix and spaces around '=', '#'. The rest may
+// span several physical lines, for a quoted multiline value.
+var variableRegex = regexp.MustCompile(`(?s)^\s*([#;])?\s*(?:export\s+)?('?[A-Za-z_][A-Za-z0-9_]*'?)\s*=\s*(.*)$`)
+
+// exportRegex matches variable lines with the 'export' keyword, commented out or not.
+var exportRegex = regexp.MustCompile(`^\s*(?:[#;]\s*)?export\s`)
+
+// Errors of a quoted value whose closing quote is missing on its line, which
+// may be found on the following lines.
+var (
+	errUnterminatedSingle = errors.New("unterminated single-quoted value")
+	errUnterminatedDouble = errors.New("unterminated double-quoted value")
+)
+
+// shebangPrefix starts an interpreter directive on the first line of a file
+// meant to be executed or sourced by a shell, e.g. "#!/usr/bin/env bash".
+const shebangPrefix = "#!"
+
+// isShebang reports whether line is the interpreter directive of its file.
+func isShebang(line *Line) bool {
+	return line.LineNumber == 1 && line.Type == LineTypeComment && strings.HasPrefix(line.OriginalContent, shebangPrefix)
+}
+
+// isDirective reports whether a comment line is shell code rather than an
+// actual comment, e.g. a shebang or "set -a". Such lines are kept verbatim.
+func (pd *ParsedData) isDirective(line *Line) bool {
+	if line.Type != LineTypeComment {
+		return false
+	}
+	if isShebang(line) {
+		return true
+	}
+	trimmed := strings.TrimSpace(line.OriginalContent)
+	return !strings.HasPrefix(trimmed, "#") && !strings.HasPrefix(trimmed, pd.commentMarker())
+}
+
+// DefaultCommentMarker is the comment marker of regular .env files.
+const DefaultCommentMarker = "#"
+
+// Options tunes how .env content is parsed.
+type Options struct {
+	// CommentMarker is an additional comment marker for dialects such as ";".
+	// Lines starting with it are comments, and variables are commented out with it.
+	// '#' is always recognized. Empty means DefaultCommentMarker.
+	CommentMarker string
+
+	// KeepFragment makes an unquoted value run to the end of its line: a '#'
+	// after whitespace is kept in the value instead of starting an inline
+	// comment, e.g. for "URL=http://host/ #section". Meant for reading files,
+	// such as to export them.
+	KeepFragment bool
+}
+
+// ParseCommentMarker validates a comment marker.
+func ParseCommentMarker(s string) (string, error) {
+	switch s {
+	case "#", ";":
+		return s, nil
+	}
+	return "", fmt.Errorf("invalid comment marker %q (expected # or ;)", s)
+}
 
 // ParseFile reads and parses the specified .env file.
 func ParseFile(filePath string) (*ParsedData, error) {
+	return ParseFileWithOptions(filePath, Options{})
+}
+
+// ParseFileWithOptions reads and parses the specified .env file with the given options.
+func ParseFileWithOptions(filePath string, opts Options) (*ParsedData, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("error opening file %s: %w", filePath, err)
 	}
 	defer file.Close()
 
+	reader := bufio.NewReader(file)
+	if looksBinary(reader) {
+		return nil, fmt.Errorf("cannot open %s: %w", filePath, ErrBinaryFile)
+	}
+	return ParseWithOptions(reader, filePath, opts)
+}
+
+// ErrBinaryFile is returned when opening a file that isn't UTF-8 text.
+var ErrBinaryFile = errors.New("the file looks binary, expected a UTF-8 text .env file")
+
+// binarySniffLen is how many leading bytes are inspected to detect binary files.
+const binarySniffLen = 8000
+
+// looksBinary reports whether the start of r contains null bytes or invalid UTF-8.
+func looksBinary(r *bufio.Reader) bool {
+	head, _ := r.Peek(binarySniffLen)
+	if bytes.IndexByte(head, 0) != -1 {
+		return true
+	}
+	for len(head) > 0 {
+		rn, size := utf8.DecodeRune(head)
+		if rn == utf8.RuneError && size == 1 {
+			// A rune cut by the sniffing limit is incomplete, not invalid
+			return utf8.FullRune(head)
+		}
+		head = head[size:]
+	}
+	return false
+}
+
+// Parse parses .env content from r.
+// sourceFile is recorded on every line as its provenance and used in error messages.
+func Parse(r io.Reader, sourceFile string) (*ParsedData, error) {
+	return ParseWithOptions(r, sourceFile, Options{})
+}
+
+// ParseWithOptions parses .env content from r with the given options.
+func ParseWithOptions(r io.Reader, sourceFile string, opts Options) (*ParsedData, error) {
+	filePath := sourceFile
+	marker := opts.CommentMarker
+	if marker == "" {
+		marker = DefaultCommentMarker
+	}
+
 	parsedData := &ParsedData{
 		Lines:          []*Line{},
 		VariableGroups: make(map[string]*VariableGroup),
 		GroupOrder:     []string{},
+		CommentMarker:  marker,
+		KeepFragment:   opts.KeepFragment,
 	}
-	scanner := bufio.NewScanner(file)
-	lineNumber := 0
+	var managedStart *Line // Start marker of a managed block not yet closed
 
-	for scanner.Scan() {
-		lineNumber++
-		originalLine := scanner.Text()
-		// Keep trimmedLine for blank/comment checks, but parse originalLine for variables
-		trimmedLine := strings.TrimSpace(originalLine)
-
-		line := &Line{
-			OriginalContent: originalLine,
-			LineNumber:      lineNumber,
-		}
-
-		if trimmedLine == "" {
-			line.Type = LineTypeBlank
-		} else if matches := variableRegex.FindStringSubmatch(originalLine); len(matches) == 4 {
-			// It's a variable line
-			line.Type = LineTypeVariable
-			line.IsCommentedOut = matches[1] == "#"
-
-			// Process Key (remove optional single quotes)
-			keyRaw := matches[2]
-			if len(keyRaw) >= 2 && keyRaw[0] == '\'' && keyRaw[len(keyRaw)-1] == '\'' {
-				line.Key = keyRaw[1 : len(keyRaw)-1]
-				// Basic validation: ensure key name is valid after removing quotes
-				if !isValidKey(line.Key) {
-					// Treat as a comment if the key is invalid after de-quoting
-					// Or return an error, depending on desired strictness
-					line.Type = LineTypeComment
-					line.Key = "" // Clear invalid key
-					parsedData.Lines = append(parsedData.Lines, line)
-					continue // Skip variable processing
-				}
-			} else {
-				line.Key = keyRaw
-				if !isValidKey(line.Key) {
-					// Treat as a comment if the key is invalid
-					line.Type = LineTypeComment
-					line.Key = "" // Clear invalid key
-					parsedData.Lines = append(parsedData.Lines, line)
-					continue // Skip variable processing
-				}
-			}
+	opts.CommentMarker = marker
+	err := streamLines(r, filePath, opts, func(line *Line) error {
+		parsedData.appendLine(line, &managedStart)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-			// Process Value (handle quotes, escapes, inline comments)
-			valueRaw, err := parseValueAndComment(matches[3])
-			if err != nil {
-				// Handle potential parsing errors (e.g., unterminated quotes)
-				// Option 1: Treat as comment
-				// line.Type = LineTypeComment
-				// line.Key = "" // Clear key if value is bad? Or keep it?
-				// Option 2: Return error
-				return nil, fmt.Errorf("error parsing line %d: %w", lineNumber, err)
-				// Option 3: Log warning and treat as comment (simplest for now)
-				// fmt.Printf("Warning: Line %d parsing error: %v. Treating as comment.\n", lineNumber, err)
-				// line.Type = LineTypeComment
-				// line.Key = ""
-			} else {
-				line.Value = valueRaw
+	// Determine initial active state for each group
+	determineInitialSelectedStates(parsedData.VariableGroups)
+	parsedData.DetectConflicts()
+
+	return parsedData, nil
+}
+
+// Clone returns a deep copy of pd, whose lines and groups can be changed
+// without affecting pd, e.g. to normalize them for a save that may not happen.
+func (pd *ParsedData) Clone() *ParsedData {
+	clone := &ParsedData{
+		Lines:          make([]*Line, len(pd.Lines)),
+		VariableGroups: make(map[string]*VariableGroup, len(pd.VariableGroups)),
+		GroupOrder:     slices.Clone(pd.GroupOrder),
+		CommentMarker:  pd.CommentMarker,
+		Conflicts:      slices.Clone(pd.Conflicts),
+		KeepFragment:   pd.KeepFragment,
+	}
+	copies := make(map[*Line]*Line, len(pd.Lines))
+	copyOf := func(line *Line) *Line {
+		if line == nil {
+			return nil
+		}
+		if c, ok := copies[line]; ok {
+			return c
+		}
+		c := *line
+		copies[line] = &c
+		return &c
+	}
+	for i, line := range pd.Lines {
+		clone.Lines[i] = copyOf(line)
+	}
+	for key, group := range pd.VariableGroups {
+		g := *group
+		g.Lines = make([]*Line, len(group.Lines))
+		for i, line := range group.Lines {
+			g.Lines[i] = copyOf(line)
+		}
+		clone.VariableGroups[key] = &g
+	}
+	if pd.Managed != nil {
+		clone.Managed = &ManagedBlock{Start: copyOf(pd.Managed.Start), End: copyOf(pd.Managed.End)}
+	}
+	return clone
+}
+
+// Concat joins files parsed separately into a single ParsedData, in order, as
+// when editing a file along with the files it includes. Variables are grouped
+// across parts and their active state is determined anew from the lines.
+func Concat(parts ...*ParsedData) *ParsedData {
+	concatenated := &ParsedData{
+		Lines:          []*Line{},
+		VariableGroups: make(map[string]*VariableGroup),
+		GroupOrder:     []string{},
+		CommentMarker:  DefaultCommentMarker,
+	}
+	if len(parts) > 0 {
+		concatenated.CommentMarker = parts[0].commentMarker()
+	}
+	var managedStart *Line
+	for _, part := range parts {
+		for _, line := range part.Lines {
+			concatenated.appendLine(line, &managedStart)
+		}
+	}
+	determineInitialSelectedStates(concatenated.VariableGroups)
+	concatenated.DetectConflicts()
+	return concatenated
+}
+
+// appendLine adds a parsed line at the end of pd, grouping variable lines by
+// key. The group's active state is left to determineInitialSelectedStates.
+func (pd *ParsedData) appendLine(line *Line, managedStart **Line) {
+	switch line.Type {
+	case LineTypeVariable:
+		// Add to VariableGroup
+		if _, ok := pd.VariableGroups[line.Key]; !ok {
+			pd.VariableGroups[line.Key] = &VariableGroup{
+				Key:             line.Key,
+				Lines:           []*Line{},
+				IsSelected:      false, // Determined later
+				SelectedLineIdx: -1,    // Determined later
 			}
+			pd.GroupOrder = append(pd.GroupOrder, line.Key)
+		}
+		group := pd.VariableGroups[line.Key]
+		group.Lines = append(group.Lines, line)
+	case LineTypeComment:
+		trackManagedMarker(pd, line, strings.TrimSpace(line.OriginalContent), managedStart)
+	}
+	pd.Lines = append(pd.Lines, line)
+}
+
+// Stream parses .env content from r line by line, calling fn with each line
+// in order without building a ParsedData, which keeps memory use flat on huge
+// files. Lines aren't grouped, so variable occurrences are reported as they come.
+// Streaming stops at the first error, including one returned by fn.
+func Stream(r io.Reader, fn func(*Line) error) error {
+	return streamLines(r, "", Options{CommentMarker: DefaultCommentMarker}, fn)
+}
+
+// StreamFile streams the lines of the specified .env file (see Stream).
+func StreamFile(filePath string, fn func(*Line) error) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("error opening file %s: %w", filePath, err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	if looksBinary(reader) {
+		return fmt.Errorf("cannot open %s: %w", filePath, ErrBinaryFile)
+	}
+	return streamLines(reader, filePath, Options{CommentMarker: DefaultCommentMarker}, fn)
+}
+
+// streamLines scans r and calls fn with each parsed line.
+// A quoted value left open on its line continues on the following lines until
+// the quote closes, the physical lines being joined into a single Line. The
+// value of a commented-out variable only continues on commented lines: if its
+// quote doesn't close before an uncommented line, its first line is a plain
+// comment and the following ones are parsed on their own. opts.CommentMarker
+// must be set.
+func streamLines(r io.Reader, sourceFile string, opts Options, fn func(*Line) error) error {
+	marker := opts.CommentMarker
+	scanner := bufio.NewScanner(r)
+	var queue []string // Physical lines read ahead, to be parsed again
+	next := func() (string, bool) {
+		if len(queue) > 0 {
+			physical := queue[0]
+			queue = queue[1:]
+			return physical, true
+		}
+		if !scanner.Scan() {
+			return "", false
+		}
+		return scanner.Text(), true
+	}
 
-			// If parsing resulted in treating it as a comment, skip group logic
-			if line.Type == LineTypeComment {
-				parsedData.Lines = append(parsedData.Lines, line)
-				continue
+	lineNumber := 0
+	for {
+		content, ok := next()
+		if !ok {
+			break
+		}
+		lineNumber++
+		start := lineNumber
+		line, err := parseLine(content, start, sourceFile, opts)
+		commented := isCommentLine(content, marker)
+		var continuations []string
+		for isUnterminated(err) {
+			physical, ok := next()
+			if !ok {
+				break
+			}
+			if commented && !isCommentLine(physical, marker) {
+				queue = append([]string{physical}, queue...)
+				break
 			}
+			lineNumber++
+			continuations = append(continuations, physical)
+			joined := content + "\n" + strings.Join(continuations, "\n")
+			var nextErr error
+			if line, nextErr = parseLine(joined, start, sourceFile, opts); !isUnterminated(nextErr) {
+				err = nextErr
+			}
+		}
+		if isUnterminated(err) && commented {
+			// A quote opened in a comment rather than a commented-out value
+			line, err = &Line{OriginalContent: content, Type: LineTypeComment, LineNumber: start, SourceFile: sourceFile}, nil
+			queue = append(continuations, queue...)
+			lineNumber = start
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading file %s: %w", sourceFile, err)
+	}
+	return nil
+}
 
-			// Add to VariableGroup
-			if _, ok := parsedData.VariableGroups[line.Key]; !ok {
-				parsedData.VariableGroups[line.Key] = &VariableGroup{
-					Key:             line.Key,
-					Lines:           []*Line{},
-					IsSelected:      false, // Determined later
-					SelectedLineIdx: -1,    // Determined later
+// isCommentLine reports whether a physical line starts with a comment marker.
+func isCommentLine(content, marker string) bool {
+	trimmed := strings.TrimSpace(content)
+	return strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, marker)
+}
+
+// isUnterminated reports whether err is about a quoted value not closed on its line.
+func isUnterminated(err error) bool {
+	return errors.Is(err, errUnterminatedSingle) || errors.Is(err, errUnterminatedDouble)
+}
+
+// uncommentContinuations removes the comment marker starting each continuation
+// line of a commented-out multiline value, with the separator after it.
+func uncommentContinuations(rest, marker string) string {
+	physical := strings.Split(rest, "\n")
+	for i := 1; i < len(physical); i++ {
+		_, unindented := splitIndentation(physical[i])
+		for _, prefix := range []string{"#", marker} {
+			if strings.HasPrefix(unindented, prefix) {
+				unindented = strings.TrimPrefix(unindented, prefix)
+				if strings.HasPrefix(unindented, " ") || strings.HasPrefix(unindented, "\t") {
+					unindented = unindented[1:]
 				}
-				parsedData.GroupOrder = append(parsedData.GroupOrder, line.Key)
+				physical[i] = unindented
+				break
 			}
-			group := parsedData.VariableGroups[line.Key]
-			group.Lines = append(group.Lines, line)
+		}
+	}
+	return strings.Join(physical, "\n")
+}
 
-		} else if strings.HasPrefix(trimmedLine, "#") {
-			line.Type = LineTypeComment
-		} else {
-			// Treat other non-empty, non-comment, non-variable lines as comments
+// parseLine classifies a single line and extracts its variable, if any.
+// opts.CommentMarker must be set.
+func parseLine(originalLine string, lineNumber int, sourceFile string, opts Options) (*Line, error) {
+	marker := opts.CommentMarker
+	// Keep trimmedLine for blank/comment checks, but parse originalLine for variables
+	trimmedLine := strings.TrimSpace(originalLine)
+
+	line := &Line{
+		OriginalContent: originalLine,
+		LineNumber:      lineNumber,
+		SourceFile:      sourceFile,
+	}
+
+	matches := variableRegex.FindStringSubmatch(originalLine)
+	switch {
+	case trimmedLine == "":
+		line.Type = LineTypeBlank
+	case lineNumber == 1 && strings.HasPrefix(originalLine, shebangPrefix):
+		// Interpreter directive, kept verbatim even if it happens to contain a '='
+		line.Type = LineTypeComment
+	case len(matches) == 4 && (matches[1] == "" || matches[1] == "#" || matches[1] == marker):
+		// It's a variable line
+		line.Type = LineTypeVariable
+		line.IsCommentedOut = matches[1] != ""
+		line.SpaceAroundEquals = hasSpaceAroundEquals(originalLine)
+		line.Exported = exportRegex.MatchString(originalLine)
+
+		// Process Key (remove optional single quotes)
+		keyRaw := matches[2]
+		if len(keyRaw) >= 2 && keyRaw[0] == '\'' && keyRaw[len(keyRaw)-1] == '\'' {
+			keyRaw = keyRaw[1 : len(keyRaw)-1]
+		}
+		if !IsValidKey(keyRaw) {
+			// Treat as a comment if the key is invalid (after de-quoting)
 			line.Type = LineTypeComment
+			line.Invalid = matches[1] == ""
+			line.IsCommentedOut = false
+			line.SpaceAroundEquals = false
+			return line, nil
+		}
+		line.Key = keyRaw
+
+		// Process Value (handle quotes, escapes, inline comments)
+		rest := matches[3]
+		if line.IsCommentedOut {
+			rest = uncommentContinuations(rest, marker)
+		}
+		value, valueRaw, comment, quoteType, err := parseValueAndComment(rest, opts.KeepFragment)
+		if err != nil {
+			// Unterminated quotes and the like make the whole file invalid
+			return nil, fmt.Errorf("error parsing line %d: %w", lineNumber, err)
 		}
+		line.Value = value
+		line.RawValue = valueRaw
+		line.Comment = comment
+		line.QuoteType = quoteType
+	default:
+		// Comments, and any other non-empty, non-variable line
+		line.Type = LineTypeComment
+		line.Invalid = !strings.HasPrefix(trimmedLine, "#") && !strings.HasPrefix(trimmedLine, marker)
+	}
+	return line, nil
+}
 
-		parsedData.Lines = append(parsedData.Lines, line)
+// commentMarker returns the marker used to comment out variables.
+func (pd *ParsedData) commentMarker() string {
+	if pd.CommentMarker == "" {
+		return DefaultCommentMarker
 	}
+	return pd.CommentMarker
+}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading file %s: %w", filePath, err)
+// SourceFiles returns the distinct source files of the parsed lines,
+// in the order they first appear.
+func (pd *ParsedData) SourceFiles() []string {
+	files := []string{}
+	seen := make(map[string]bool)
+	for _, line := range pd.Lines {
+		if !seen[line.SourceFile] {
+			seen[line.SourceFile] = true
+			files = append(files, line.SourceFile)
+		}
 	}
+	return files
+}
 
-	// Determine initial active state for each group
-	determineInitialSelectedStates(parsedData.VariableGroups)
+// HeaderEnd returns the number of leading comment and blank lines before the
+// first variable line (or managed block), e.g. a descriptive header or a shebang.
+// These lines are pinned to the top of the file: operations adding or moving
+// lines never place anything before them. A file without variables is all header.
+func (pd *ParsedData) HeaderEnd() int {
+	for i, line := range pd.Lines {
+		if line.Type == LineTypeVariable || (pd.Managed != nil && line == pd.Managed.Start) {
+			return i
+		}
+	}
+	return len(pd.Lines)
+}
 
-	return parsedData, nil
+// insertLine inserts line at index in Lines, never before the leading header.
+func (pd *ParsedData) insertLine(index int, line *Line) {
+	index = max(index, pd.HeaderEnd())
+	pd.Lines = append(pd.Lines[:index], append([]*Line{line}, pd.Lines[index:]...)...)
 }
 
-// isValidKey checks if a string is a valid unquoted key name.
+// AddVariable adds a new active occurrence of key with the given value after
+// the last occurrence of key, or at the end of the file if key is new, creating
+// its group if needed. It returns the new line.
+func (pd *ParsedData) AddVariable(key, value string) (*Line, error) {
+	if !IsValidKey(key) {
+		return nil, fmt.Errorf("invalid key %q", key)
+	}
+	group, ok := pd.VariableGroups[key]
+	index := len(pd.Lines)
+	if ok && len(group.Lines) > 0 {
+		index = pd.lineIndex(group.Lines[len(group.Lines)-1]) + 1
+	}
+
+	source := ""
+	if index > 0 {
+		source = pd.Lines[index-1].SourceFile
+	}
+	prefix := group.exportPrefix()
+	line := &Line{
+		OriginalContent: prefix + key + "=",
+		Type:            LineTypeVariable,
+		SourceFile:      source,
+		Key:             key,
+		Exported:        prefix != "",
+	}
+	line.SetValue(value)
+	pd.Lines = slices.Insert(pd.Lines, index, line)
+
+	if !ok {
+		group = &VariableGroup{Key: key}
+		pd.VariableGroups[key] = group
+		pd.GroupOrder = append(pd.GroupOrder, key)
+	}
+	group.Lines = append(group.Lines, line)
+	group.IsSelected = true
+	group.SelectedLineIdx = len(group.Lines) - 1
+	return line, nil
+}
+
+// RemoveOccurrence deletes the occurrence at index of a variable from the
+// file. Removing the active occurrence leaves the variable inactive, and
+// removing the last one removes the variable altogether.
+func (pd *ParsedData) RemoveOccurrence(key string, index int) error {
+	group, ok := pd.VariableGroups[key]
+	if !ok {
+		return fmt.Errorf("unknown variable %q", key)
+	}
+	if index < 0 || index >= len(group.Lines) {
+		return fmt.Errorf("%s has no occurrence %d", key, index)
+	}
+	if i := pd.lineIndex(group.Lines[index]); i != -1 {
+		pd.Lines = slices.Delete(pd.Lines, i, i+1)
+	}
+	group.Lines = slices.Delete(group.Lines, index, index+1)
+
+	switch {
+	case len(group.Lines) == 0:
+		delete(pd.VariableGroups, key)
+		pd.GroupOrder = slices.DeleteFunc(pd.GroupOrder, func(k string) bool { return k == key })
+	case index == group.SelectedLineIdx:
+		group.IsSelected = false
+		group.SelectedLineIdx = 0 // Remembered for when the variable is reactivated
+	case index < group.SelectedLineIdx:
+		group.SelectedLineIdx--
+	}
+	pd.pruneConflicts()
+	return nil
+}
+
+// RemoveVariable deletes every occurrence of a variable from the file.
+func (pd *ParsedData) RemoveVariable(key string) error {
+	group, ok := pd.VariableGroups[key]
+	if !ok {
+		return fmt.Errorf("unknown variable %q", key)
+	}
+	pd.Lines = slices.DeleteFunc(pd.Lines, func(line *Line) bool { return slices.Contains(group.Lines, line) })
+	delete(pd.VariableGroups, key)
+	pd.GroupOrder = slices.DeleteFunc(pd.GroupOrder, func(k string) bool { return k == key })
+	pd.pruneConflicts()
+	return nil
+}
+
+// SortGroupByComment reorders the occurrences of a variable alphabetically by
+// their inline comment (case-insensitive, lines without a comment last), keeping
+// the active line selected. The lines swap places in Lines, so the file is
+// written in the new order. It fails if the occurrences span several files.
+func (pd *ParsedData) SortGroupByComment(key string) error {
+	group, ok := pd.VariableGroups[key]
+	if !ok {
+		return fmt.Errorf("unknown variable %q", key)
+	}
+	sorted := append([]*Line(nil), group.Lines...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := strings.ToLower(sorted[i].Comment), strings.ToLower(sorted[j].Comment)
+		if a == "" || b == "" {
+			return a != "" && b == ""
+		}
+		return a < b
+	})
+	return pd.reorderGroup(group, sorted)
+}
+
+// reorderGroup puts the group's lines in the given order, both in the group
+// and in the slots they occupy in Lines.
+func (pd *ParsedData) reorderGroup(group *VariableGroup, order []*Line) error {
+	slots := make([]int, 0, len(group.Lines))
+	for i, line := range pd.Lines {
+		if line.Type == LineTypeVariable && line.Key == group.Key {
+			if line.SourceFile != group.Lines[0].SourceFile {
+				return fmt.Errorf("occurrences of %s span several files", group.Key)
+			}
+			slots = append(slots, i)
+		}
+	}
+	if len(slots) != len(order) {
+		return fmt.Errorf("occurrences of %s are out of sync", group.Key)
+	}
+
+	var selected *Line
+	if group.SelectedLineIdx >= 0 && group.SelectedLineIdx < len(group.Lines) {
+		selected = group.Lines[group.SelectedLineIdx]
+	}
+	for i, slot := range slots {
+		pd.Lines[slot] = order[i]
+		if order[i] == selected {
+			group.SelectedLineIdx = i
+		}
+	}
+	group.Lines = order
+	return nil
+}
+
+// Resolve returns the active value of key with its ${VAR} and $VAR
+// references expanded recursively, using the active value of each referenced
+// variable. References to unknown or inactive variables expand to an empty
+// string, and single-quoted values are taken literally. A reference cycle is
+// an error. The parsed values are left untouched.
+func (pd *ParsedData) Resolve(key string) (string, error) {
+	return pd.resolveKey(key, nil)
+}
+
+// ResolveLine returns the value of a variable line with its references
+// expanded as Resolve does, whether the line is active or not.
+func (pd *ParsedData) ResolveLine(line *Line) (string, error) {
+	return pd.resolveLine(line, nil)
+}
+
+// resolveKey implements Resolve, stack holding the keys being resolved.
+func (pd *ParsedData) resolveKey(key string, stack []string) (string, error) {
+	if slices.Contains(stack, key) {
+		return "", fmt.Errorf("reference cycle: %s -> %s", strings.Join(stack, " -> "), key)
+	}
+	group, ok := pd.VariableGroups[key]
+	if !ok {
+		return "", nil
+	}
+	line := group.ActiveLine()
+	if line == nil {
+		return "", nil
+	}
+	return pd.resolveLine(line, stack)
+}
+
+// resolveLine implements ResolveLine, stack holding the keys being resolved.
+func (pd *ParsedData) resolveLine(line *Line, stack []string) (string, error) {
+	if line.QuoteType == '\'' {
+		return line.Value, nil
+	}
+	stack = append(slices.Clip(stack), line.Key)
+	var err error
+	resolved := os.Expand(line.Value, func(name string) string {
+		if err != nil {
+			return ""
+		}
+		var value string
+		value, err = pd.resolveKey(name, stack)
+		return value
+	})
+	if err != nil {
+		return "", err
+	}
+	return resolved, nil
+}
+
+// SetValue changes the value of a variable line, rewriting its content.
+// The key, comment marker, spacing before the value and inline comment are
+// kept; the value is quoted when needed (see QuoteValue).
+func (l *Line) SetValue(value string) {
+	if l.Type != LineTypeVariable {
+		return
+	}
+	_, valueStart, ok := assignmentBounds(l.OriginalContent)
+	if !ok {
+		return
+	}
+	comment := l.OriginalContent[len(withoutInlineComment(l.OriginalContent, false)):]
+	if strings.HasPrefix(comment, "#") {
+		comment = " " + comment // Only whitespace starts a comment after an unquoted value
+	}
+	quoted := QuoteValue(value)
+	if quoted == "" && comment != "" {
+		quoted = `""` // An empty unquoted value would read the comment as the value
+	}
+	l.OriginalContent = l.OriginalContent[:valueStart] + quoted + comment
+	l.Value = value
+	l.RawValue = quoted
+	l.QuoteType = 0
+	if quoted != value {
+		l.RawValue = quoted[1 : len(quoted)-1]
+		l.QuoteType = rune(quoted[0])
+	}
+}
+
+// KeyCase is a key naming policy applied when writing the file.
+type KeyCase string
+
+const (
+	KeyCasePreserve KeyCase = "preserve"
+	KeyCaseUpper    KeyCase = "upper"
+	KeyCaseLower    KeyCase = "lower"
+)
+
+// ParseKeyCase validates a key case policy name.
+func ParseKeyCase(s string) (KeyCase, error) {
+	switch KeyCase(s) {
+	case KeyCasePreserve, KeyCaseUpper, KeyCaseLower:
+		return KeyCase(s), nil
+	}
+	return "", fmt.Errorf("invalid key case %q (expected upper, lower or preserve)", s)
+}
+
+// Apply returns key converted according to the policy.
+func (kc KeyCase) Apply(key string) string {
+	switch kc {
+	case KeyCaseUpper:
+		return strings.ToUpper(key)
+	case KeyCaseLower:
+		return strings.ToLower(key)
+	}
+	return key
+}
+
+// NormalizeKeyCase renames every variable according to the policy, rewriting
+// the lines' content and keeping VariableGroups and GroupOrder consistent.
+// Groups whose keys become identical are merged, keeping the first one's selection.
+func (pd *ParsedData) NormalizeKeyCase(kc KeyCase) {
+	if kc == KeyCasePreserve || kc == "" {
+		return
+	}
+
+	groups := make(map[string]*VariableGroup)
+	order := []string{}
+	for _, oldKey := range pd.GroupOrder {
+		group := pd.VariableGroups[oldKey]
+		newKey := kc.Apply(oldKey)
+		for _, line := range group.Lines {
+			renameLineKey(line, newKey)
+		}
+
+		merged, ok := groups[newKey]
+		if !ok {
+			group.Key = newKey
+			groups[newKey] = group
+			order = append(order, newKey)
+			continue
+		}
+		// Merge into the group that already uses the normalized key
+		if !merged.IsSelected && group.IsSelected {
+			merged.IsSelected = true
+			merged.SelectedLineIdx = len(merged.Lines) + group.SelectedLineIdx
+		}
+		merged.Lines = append(merged.Lines, group.Lines...)
+	}
+
+	pd.VariableGroups = groups
+	pd.GroupOrder = order
+}
+
+// LookupKey returns the key of the variable named key. With ignoreCase, a key
+// differing only in case matches when there is no exact match, and it is an
+// error for several keys to match. ok is false if no key matches.
+func (pd *ParsedData) LookupKey(key string, ignoreCase bool) (match string, ok bool, err error) {
+	if _, exists := pd.VariableGroups[key]; exists || !ignoreCase {
+		return key, exists, nil
+	}
+	var matches []string
+	for _, candidate := range pd.GroupOrder {
+		if strings.EqualFold(candidate, key) {
+			matches = append(matches, candidate)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return key, false, nil
+	case 1:
+		return matches[0], true, nil
+	default:
+		return "", false, fmt.Errorf("key %s is ambiguous, it matches %s", key, strings.Join(matches, ", "))
+	}
+}
+
+// renameLineKey replaces the key of a variable line, in both Key and OriginalContent.
+func renameLineKey(line *Line, newKey string) {
+	if line.Key == newKey {
+		return
+	}
+	idx := variableRegex.FindStringSubmatchIndex(line.OriginalContent)
+	if idx != nil && idx[4] >= 0 {
+		start, end := idx[4], idx[5]
+		if line.OriginalContent[start] == '\'' {
+			// Keep the quotes around quoted keys
+			start++
+			end--
+		}
+		line.OriginalContent = line.OriginalContent[:start] + newKey + line.OriginalContent[end:]
+	}
+	line.Key = newKey
+}
+
+// Spacing is a policy for the whitespace around '=' applied when writing the file.
+type Spacing string
+
+const (
+	SpacingPreserve Spacing = "preserve" // Keep each line's spacing
+	SpacingCompact  Spacing = "compact"  // KEY=value
+	SpacingSpaced   Spacing = "spaced"   // KEY = value
+)
+
+// ParseSpacing validates a spacing policy name.
+func ParseSpacing(s string) (Spacing, error) {
+	switch Spacing(s) {
+	case SpacingPreserve, SpacingCompact, SpacingSpaced:
+		return Spacing(s), nil
+	}
+	return "", fmt.Errorf("invalid spacing %q (expected compact, spaced or preserve)", s)
+}
+
+// NormalizeSpacing rewrites the whitespace around '=' of every variable line
+// according to the policy.
+func (pd *ParsedData) NormalizeSpacing(sp Spacing) {
+	if sp == SpacingPreserve || sp == "" {
+		return
+	}
+	for _, line := range pd.Lines {
+		if line.Type == LineTypeVariable {
+			setLineSpacing(line, sp == SpacingSpaced)
+		}
+	}
+}
+
+// TrimTrailingBlankLines removes the blank lines at the end of each source
+// file, so that it ends with a single newline. Blank lines between other lines
+// are kept.
+func (pd *ParsedData) TrimTrailingBlankLines() {
+	hasContentAfter := make(map[string]bool) // By source file, while walking backwards
+	kept := make([]*Line, 0, len(pd.Lines))
+	for i := len(pd.Lines) - 1; i >= 0; i-- {
+		line := pd.Lines[i]
+		if line.Type == LineTypeBlank && !hasContentAfter[line.SourceFile] {
+			continue
+		}
+		hasContentAfter[line.SourceFile] = true
+		kept = append(kept, line)
+	}
+	slices.Reverse(kept)
+	pd.Lines = kept
+}
+
+// setLineSpacing rewrites the assignment of a variable line as "KEY=" or "KEY = ".
+func setLineSpacing(line *Line, spaced bool) {
+	keyEnd, valueStart, ok := assignmentBounds(line.OriginalContent)
+	if !ok {
+		return
+	}
+	assignment := "="
+	if spaced {
+		assignment = " = "
+	}
+	line.OriginalContent = line.OriginalContent[:keyEnd] + assignment + line.OriginalContent[valueStart:]
+	line.SpaceAroundEquals = spaced
+}
+
+// hasSpaceAroundEquals reports whether the '=' of a variable line is surrounded by whitespace.
+func hasSpaceAroundEquals(content string) bool {
+	keyEnd, valueStart, ok := assignmentBounds(content)
+	return ok && valueStart-keyEnd > 1
+}
+
+// assignmentBounds returns the indexes, in the content of a variable line, of
+// the end of the key and of the start of the value. Only the first '=' separates
+// the key from the value: keys can't contain '=', so "KEY=a=b=c" holds "a=b=c",
+// and rewriting the value never splits it on a later '='.
+func assignmentBounds(content string) (keyEnd, valueStart int, ok bool) {
+	idx := variableRegex.FindStringSubmatchIndex(content)
+	if idx == nil || idx[5] < 0 || idx[6] < 0 {
+		return 0, 0, false
+	}
+	return idx[5], idx[6], true
+}
+
+// keyValidationRegex matches valid unquoted key names.
 var keyValidationRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
 
-func isValidKey(key string) bool {
+// IsValidKey checks if a string is a valid unquoted key name.
+func IsValidKey(key string) bool {
 	return keyValidationRegex.MatchString(key)
 }
 
-// parseValueAndComment extracts the value from the rest of the line,
-// handling quotes, escapes, and inline comments.
-func parseValueAndComment(input string) (string, error) {
+// parseValueAndComment extracts the value, unescaped and as written, the inline
+// comment and the quote around the value (0 if unquoted) from the rest of the
+// line, handling quotes, escapes, and inline comments. With keepFragment, an
+// unquoted value has no inline comment (see Options.KeepFragment).
+func parseValueAndComment(input string, keepFragment bool) (value, valueRaw, comment string, quoteType rune, err error) {
 	input = strings.TrimLeft(input, " \t") // Trim leading space only
 
 	if input == "" {
-		return "", nil // Empty value
+		return "", "", "", 0, nil // Empty value
 	}
 
-	var valueRaw string
-	var quoteType rune = 0 // 0 = unquoted, '\'' = single, '"' = double
+	var rest string // Remainder of the line after the value
 
 	switch input[0] {
 	case '\'':
@@ -200,9 +984,10 @@ func parseValueAndComment(input string) (string, error) {
 			escaped = input[i] == '\\' && !escaped
 		}
 		if endQuoteIdx == -1 {
-			return "", errors.New("unterminated single-quoted value")
+			return "", "", "", 0, errUnterminatedSingle
 		}
 		valueRaw = input[1:endQuoteIdx]
+		rest = input[endQuoteIdx+1:]
 		// Check for inline comment after closing quote
 		// commentPart := strings.TrimSpace(input[endQuoteIdx+1:])
 		// if len(commentPart) > 0 && !strings.HasPrefix(commentPart, "#") {
@@ -220,9 +1005,10 @@ func parseValueAndComment(input string) (string, error) {
 			escaped = input[i] == '\\' && !escaped
 		}
 		if endQuoteIdx == -1 {
-			return "", errors.New("unterminated double-quoted value")
+			return "", "", "", 0, errUnterminatedDouble
 		}
 		valueRaw = input[1:endQuoteIdx]
+		rest = input[endQuoteIdx+1:]
 		// Check for inline comment after closing quote
 		// commentPart := strings.TrimSpace(input[endQuoteIdx+1:])
 		// if len(commentPart) > 0 && !strings.HasPrefix(commentPart, "#") {
@@ -231,7 +1017,7 @@ func parseValueAndComment(input string) (string, error) {
 	default:
 		// Unquoted value: find the first " #"
 		commentIdx := -1
-		for i := 0; i < len(input); i++ {
+		for i := 0; i < len(input) && !keepFragment; i++ {
 			if input[i] == '#' && i > 0 && (input[i-1] == ' ' || input[i-1] == '\t') {
 				// Found start of inline comment if # is preceded by whitespace
 				commentIdx = i - 1 // Point to the space before #
@@ -241,6 +1027,7 @@ func parseValueAndComment(input string) (string, error) {
 
 		if commentIdx != -1 {
 			valueRaw = input[:commentIdx]
+			rest = input[commentIdx:]
 		} else {
 			valueRaw = input
 		}
@@ -248,69 +1035,123 @@ func parseValueAndComment(input string) (string, error) {
 		valueRaw = strings.TrimRight(valueRaw, " \t")
 	}
 
-	// return unescapeValue(valueRaw, quoteType)
-	_ = quoteType // TODO: Remove in future
-	return valueRaw, nil
-}
-
-// unescapeValue processes escape sequences based on the quoting style.
-// func unescapeValue(raw string, quoteType rune) (string, error) {
-// 	var sb strings.Builder
-// 	sb.Grow(len(raw)) // Pre-allocate capacity
-// 	escaped := false
-
-// 	for _, r := range raw {
-// 		if escaped {
-// 			switch quoteType {
-// 			case '\'': // Single quotes: \\ and \'
-// 				switch r {
-// 				case '\\', '\'':
-// 					sb.WriteRune(r)
-// 				default:
-// 					// Invalid escape sequence for single quotes, keep literal backslash and char
-// 					// Or return error? Let's keep literal for robustness.
-// 					sb.WriteRune('\\')
-// 					sb.WriteRune(r)
-// 					// return "", fmt.Errorf("invalid escape sequence in single-quoted string: \\%c", r)
-// 				}
-// 			case '"': // Double quotes: \\, \', \"
-// 				switch r {
-// 				case '\\', '\'', '"':
-// 					sb.WriteRune(r)
-// 				// Add other common escapes if needed later (e.g., \n, \t), but not per user spec yet
-// 				// case 'n': sb.WriteRune('\n')
-// 				// case 'r': sb.WriteRune('\r')
-// 				// case 't': sb.WriteRune('\t')
-// 				default:
-// 					// Invalid escape sequence for double quotes, keep literal backslash and char
-// 					sb.WriteRune('\\')
-// 					sb.WriteRune(r)
-// 					// return "", fmt.Errorf("invalid escape sequence in double-quoted string: \\%c", r)
-// 				}
-// 			default: // Unquoted or error case - should not happen if called correctly
-// 				// No escapes defined for unquoted values in the spec
-// 				sb.WriteRune('\\')
-// 				sb.WriteRune(r) // Treat as literal
-// 			}
-// 			escaped = false
-// 		} else if r == '\\' && (quoteType == '\'' || quoteType == '"') {
-// 			escaped = true // Potential start of an escape sequence only if quoted
-// 		} else {
-// 			sb.WriteRune(r)
-// 			escaped = false // Ensure escaped is reset if it wasn't a backslash
-// 		}
-// 	}
-
-// 	if escaped {
-// 		// Trailing backslash - treat as error or literal?
-// 		// Let's treat as literal for robustness (append the dangling backslash)
-// 		sb.WriteRune('\\')
-// 		// Alternatively, return an error:
-// 		// return "", errors.New("value ends with a dangling escape character ('\\')")
-// 	}
-
-// 	return sb.String(), nil
-// }
+	if trimmedRest := strings.TrimSpace(rest); strings.HasPrefix(trimmedRest, "#") {
+		comment = strings.TrimSpace(trimmedRest[1:])
+	}
+
+	return unescapeValue(valueRaw, quoteType), valueRaw, comment, quoteType, nil
+}
+
+// plainValueRegex matches values that can be written to a .env file without quotes.
+var plainValueRegex = regexp.MustCompile(`^[A-Za-z0-9_./:@%+,=-]*$`)
+
+// QuoteValue returns value in a form that can be pasted into a .env file as is.
+// Values containing whitespace, '#', quotes or other special characters are
+// wrapped in quotes; plain values are returned unchanged. Backslashes, double
+// quotes and control characters are escaped when double quotes are needed.
+func QuoteValue(value string) string {
+	if plainValueRegex.MatchString(value) {
+		return value
+	}
+	if !strings.ContainsAny(value, "\"\\\n\t\r") {
+		return `"` + value + `"`
+	}
+	if !strings.ContainsAny(value, "'\\\n\r") {
+		return "'" + value + "'"
+	}
+	return `"` + escapeValue(value) + `"`
+}
+
+// unescapeValue processes the escape sequences of a value written between
+// quoteType quotes. Only double-quoted values have escape sequences: \\, \",
+// \n, \t and \r; any other backslash is kept as is. Single-quoted and unquoted
+// values are literal.
+func unescapeValue(raw string, quoteType rune) string {
+	if quoteType != '"' || !strings.Contains(raw, `\`) {
+		return raw
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(raw))
+	escaped := false
+	for _, r := range raw {
+		if !escaped {
+			if r == '\\' {
+				escaped = true
+			} else {
+				sb.WriteRune(r)
+			}
+			continue
+		}
+		switch r {
+		case '\\', '"':
+			sb.WriteRune(r)
+		case 'n':
+			sb.WriteRune('\n')
+		case 't':
+			sb.WriteRune('\t')
+		case 'r':
+			sb.WriteRune('\r')
+		default:
+			// Unknown escape sequence, keep it literally
+			sb.WriteRune('\\')
+			sb.WriteRune(r)
+		}
+		escaped = false
+	}
+	if escaped {
+		sb.WriteRune('\\') // Dangling backslash, kept literally
+	}
+	return sb.String()
+}
+
+// escapeValue is the inverse of unescapeValue for double-quoted values.
+var escapeValue = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`).Replace
+
+// InvalidLines returns the lines that are neither blank, comments nor valid
+// assignments, e.g. "1FOO=bar". They are kept verbatim as comments.
+func (pd *ParsedData) InvalidLines() []*Line {
+	var invalid []*Line
+	for _, line := range pd.Lines {
+		if line.Invalid {
+			invalid = append(invalid, line)
+		}
+	}
+	return invalid
+}
+
+// DetectConflicts lists in Conflicts the keys uncommented on several lines of
+// the file, in display order. Only the first of these lines is used; saving
+// comments out all but the active one. It is called after parsing, and must be
+// called again once the lines' IsCommentedOut reflect a save.
+func (pd *ParsedData) DetectConflicts() {
+	pd.Conflicts = nil
+	for _, key := range pd.GroupOrder {
+		if uncommentedLines(pd.VariableGroups[key]) > 1 {
+			pd.Conflicts = append(pd.Conflicts, key)
+		}
+	}
+}
+
+// pruneConflicts drops from Conflicts the keys removed, or left with a single
+// uncommented line, after lines were removed.
+func (pd *ParsedData) pruneConflicts() {
+	pd.Conflicts = slices.DeleteFunc(pd.Conflicts, func(key string) bool {
+		group, ok := pd.VariableGroups[key]
+		return !ok || uncommentedLines(group) < 2
+	})
+}
+
+// uncommentedLines counts the lines of group not commented out in the file.
+func uncommentedLines(group *VariableGroup) int {
+	count := 0
+	for _, line := range group.Lines {
+		if !line.IsCommentedOut {
+			count++
+		}
+	}
+	return count
+}
 
 // determineInitialSelectedStates sets the initial IsSelected, SelectedLineIdx.
 // A group is selected if exactly one of its lines is not commented out.
@@ -343,11 +1184,7 @@ func determineInitialSelectedStates(groups map[string]*VariableGroup) {
 
 		if uncommentedCount > 0 {
 			group.IsSelected = true
-			group.SelectedLineIdx = firstUncommentedIdx
-			if uncommentedCount > 1 {
-				// Optional: Log a warning here if multiple lines for the same key are uncommented initially.
-				fmt.Printf("Warning: Multiple uncommented lines found for key '%s'. Selecting the first one.\n", group.Key)
-			}
+			group.SelectedLineIdx = firstUncommentedIdx // See DetectConflicts for the others
 		} else {
 			group.IsSelected = false
 			// Default active index to the first variable line if any, otherwise -1
@@ -0,0 +1,217 @@
+package parser
+
+import (
+	"slices"
+	"strings"
+)
+
+// OrphanedLines returns the variable lines whose group linkage is broken: their
+// key has no group, or the group doesn't hold them. RenderLines can't represent
+// these and writes them as error comments.
+func OrphanedLines(data *ParsedData) []*Line {
+	var orphans []*Line
+	for _, line := range data.Lines {
+		if line.Type != LineTypeVariable {
+			continue
+		}
+		group, ok := data.VariableGroups[line.Key]
+		if !ok || !slices.Contains(group.Lines, line) {
+			orphans = append(orphans, line)
+		}
+	}
+	return orphans
+}
+
+// RenderLines reconstructs the file content for the given lines, commenting
+// and uncommenting variable lines according to their group's selection.
+func RenderLines(lines []*Line, data *ParsedData) string {
+	return renderLines(lines, data, nil)
+}
+
+// RenderBlanked reconstructs the whole file with every value emptied, keeping
+// keys and comments, e.g. to turn a .env into a .env.example.
+func RenderBlanked(data *ParsedData) string {
+	return renderLines(data.Lines, data, func(content string) string {
+		blank := &Line{OriginalContent: content, Type: LineTypeVariable}
+		blank.SetValue("")
+		return blank.OriginalContent
+	})
+}
+
+// renderLines implements RenderLines, passing the reconstructed content of
+// variable lines through transform if not nil.
+func renderLines(lines []*Line, data *ParsedData, transform func(content string) string) string {
+	var builder strings.Builder
+	for _, line := range lines {
+		switch line.Type {
+		case LineTypeBlank, LineTypeComment:
+			builder.WriteString(line.OriginalContent)
+			builder.WriteString("\n")
+		case LineTypeVariable:
+			group, ok := data.VariableGroups[line.Key]
+			if !ok {
+				// Should not happen if parsing was correct, but handle defensively
+				builder.WriteString("# Error: Orphaned variable line! -> " + line.OriginalContent)
+				builder.WriteString("\n")
+				continue
+			}
+
+			// Find the index of this specific line within its group
+			lineIndexInGroup := -1
+			for i, groupLine := range group.Lines {
+				if groupLine == line { // Compare pointers
+					lineIndexInGroup = i
+					break
+				}
+			}
+
+			if lineIndexInGroup == -1 {
+				// Should also not happen
+				builder.WriteString("# Error: Could not find line in its group! -> " + line.OriginalContent)
+				builder.WriteString("\n")
+				continue
+			}
+
+			newLineContent := data.ReconstructVariableLine(line, group, lineIndexInGroup)
+			if transform != nil {
+				newLineContent = transform(newLineContent)
+			}
+			builder.WriteString(newLineContent)
+			builder.WriteString("\n")
+
+		default:
+			// Preserve unknown line types?
+			builder.WriteString(line.OriginalContent)
+			builder.WriteString("\n")
+		}
+	}
+
+	// Need to remove trailing newline potentially added by loop if last line wasn't blank
+	content := builder.String()
+	// Ensure file ends with a newline as per custom instructions
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return content
+}
+
+// RenderActive reconstructs a file holding only the active occurrence of each
+// variable, in file order. Comments, inline ones included, blank lines and
+// inactive occurrences are dropped.
+func RenderActive(data *ParsedData) string {
+	var active []*Line
+	for _, line := range data.Lines {
+		if line.Type != LineTypeVariable {
+			continue
+		}
+		if group, ok := data.VariableGroups[line.Key]; ok && group.ActiveLine() == line {
+			active = append(active, line)
+		}
+	}
+	if len(active) == 0 {
+		return ""
+	}
+	return renderLines(active, data, func(content string) string {
+		return withoutInlineComment(content, data.KeepFragment)
+	})
+}
+
+// withoutInlineComment removes the inline comment of a variable line, even an
+// empty one as in "KEY=value #". A '#' only starts a comment after whitespace
+// or a closing quote, so URL fragments such as http://host/#section are kept,
+// and never after an unquoted value with keepFragment (see Options.KeepFragment).
+func withoutInlineComment(content string, keepFragment bool) string {
+	_, valueStart, ok := assignmentBounds(content)
+	if !ok {
+		return content
+	}
+	rest := content[valueStart:]
+	value, _, comment, _, err := parseValueAndComment(rest, keepFragment)
+	if err != nil {
+		return content
+	}
+	if comment != "" {
+		rest = rest[:strings.LastIndex(rest, comment)]
+	}
+	rest = strings.TrimRight(rest, " \t")
+	if !strings.HasSuffix(rest, "#") {
+		return content
+	}
+	// The '#' left starts the comment, unless it is part of the value
+	rest = strings.TrimSuffix(rest, "#")
+	if v, _, _, _, err := parseValueAndComment(rest, keepFragment); err != nil || v != value {
+		return content
+	}
+	return content[:valueStart] + strings.TrimRight(rest, " \t")
+}
+
+// splitIndentation splits content into its leading spaces and tabs and the rest.
+func splitIndentation(content string) (indentation, rest string) {
+	rest = strings.TrimLeft(content, " \t")
+	return content[:len(content)-len(rest)], rest
+}
+
+// ReconstructVariableLine determines the correct content for a variable line based on its group's selection.
+// Lines are commented out with the file's comment marker.
+func (data *ParsedData) ReconstructVariableLine(line *Line, group *VariableGroup, lineIndexInGroup int) string {
+	// Reconstruct the original Key=Value part, removing any initial comment marker
+	// We stored Key and Value separately, need original spacing/quoting?
+	// Simplification: Assume standard KEY=VALUE format is okay for reconstruction.
+	// Let's try to use OriginalContent and add/remove '#' carefully.
+
+	originalContent := line.OriginalContent
+	trimmedContent := strings.TrimSpace(originalContent)
+	hasPrefix := strings.HasPrefix(trimmedContent, "#") || strings.HasPrefix(trimmedContent, data.commentMarker())
+
+	shouldBeActive := group.IsSelected && group.SelectedLineIdx == lineIndexInGroup
+
+	if strings.Contains(originalContent, "\n") && hasPrefix == shouldBeActive {
+		// Multiline value, each physical line is commented out or uncommented.
+		// Continuation lines always get a marker, even those of a value
+		// starting with '#', since the parser removes exactly one.
+		physical := strings.Split(originalContent, "\n")
+		for i, content := range physical {
+			if i > 0 && !shouldBeActive {
+				indentation, unindented := splitIndentation(content)
+				physical[i] = indentation + data.commentMarker() + " " + unindented
+			} else {
+				physical[i] = data.toggleCommentPrefix(content, shouldBeActive)
+			}
+		}
+		return strings.Join(physical, "\n")
+	}
+	return data.toggleCommentPrefix(originalContent, shouldBeActive)
+}
+
+// toggleCommentPrefix comments out a physical line, or uncomments it if active.
+func (data *ParsedData) toggleCommentPrefix(originalContent string, shouldBeActive bool) string {
+	trimmedContent := strings.TrimSpace(originalContent)
+	hasPrefix := strings.HasPrefix(trimmedContent, "#") || strings.HasPrefix(trimmedContent, data.commentMarker())
+
+	// Indentation is kept byte for byte, tabs are never turned into spaces
+	indentation, unindented := splitIndentation(originalContent)
+
+	if shouldBeActive {
+		// Needs to be uncommented
+		if hasPrefix {
+			// Remove the comment marker and the separator after it, if any
+			suffix := unindented[1:]
+			if strings.HasPrefix(suffix, " ") || strings.HasPrefix(suffix, "\t") {
+				suffix = suffix[1:]
+			}
+			return indentation + suffix
+		} else {
+			// Already uncommented, return as is
+			return originalContent
+		}
+	} else {
+		// Needs to be commented out
+		if hasPrefix {
+			// Already commented, return as is
+			return originalContent
+		} else {
+			// Add the comment marker prefix after the original indentation
+			return indentation + data.commentMarker() + " " + unindented
+		}
+	}
+}
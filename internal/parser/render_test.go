@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderActiveDropsInlineComments(t *testing.T) {
+	tests := []struct {
+		name         string
+		line         string
+		want         string
+		keepFragment string // Rendered with Options.KeepFragment
+	}{
+		{"comment", "K=v # note", "K=v", "K=v # note"},
+		{"empty comment", "K=v #", "K=v", "K=v #"},
+		{"quoted with comment", `K="a # b" # note`, `K="a # b"`, `K="a # b"`},
+		{"quoted empty comment", `K="v"#`, `K="v"`, `K="v"`},
+		{"fragment", "URL=http://host/#section", "URL=http://host/#section", "URL=http://host/#section"},
+		{"fragment with comment", "URL=http://host/#section # docs", "URL=http://host/#section", "URL=http://host/#section # docs"},
+		{"spaced fragment", "URL=http://host/ #section", "URL=http://host/", "URL=http://host/ #section"},
+		{"trailing hash", "K=a#", "K=a#", "K=a#"},
+		{"comment repeating the value", "K=note # note", "K=note", "K=note # note"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RenderActive(parse(t, tt.line+"\n")); got != tt.want+"\n" {
+				t.Errorf("RenderActive = %q, want %q", got, tt.want+"\n")
+			}
+			data, err := ParseWithOptions(strings.NewReader(tt.line+"\n"), ".env", Options{KeepFragment: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := RenderActive(data); got != tt.keepFragment+"\n" {
+				t.Errorf("RenderActive with KeepFragment = %q, want %q", got, tt.keepFragment+"\n")
+			}
+		})
+	}
+}
+
+func TestKeepFragmentValue(t *testing.T) {
+	const content = "URL=http://host/ #section\nQUOTED=\"x\" # note\n"
+	data, err := ParseWithOptions(strings.NewReader(content), ".env", Options{KeepFragment: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := data.VariableGroups["URL"].Lines[0].Value; got != "http://host/ #section" {
+		t.Errorf("URL = %q, want the fragment kept", got)
+	}
+	if got := data.VariableGroups["QUOTED"].Lines[0]; got.Value != "x" || got.Comment != "note" {
+		t.Errorf("QUOTED = %q with comment %q, want x with comment note", got.Value, got.Comment)
+	}
+	if got := render(data); got != content {
+		t.Errorf("rendered %q, want the original", got)
+	}
+}
+
+func TestSetValueKeepsEmptyComment(t *testing.T) {
+	line := parse(t, "K=v #\n").VariableGroups["K"].Lines[0]
+	line.SetValue("w")
+	if line.OriginalContent != "K=w #" {
+		t.Errorf("edited line is %q, want %q", line.OriginalContent, "K=w #")
+	}
+}
+
+func TestIndentationKeptWhenToggling(t *testing.T) {
+	const content = "\tA=1\n\t#\tA=2\n  \tB=x\n"
+	data := parse(t, content)
+
+	// Switching A to its second value and disabling B
+	data.VariableGroups["A"].SelectedLineIdx = 1
+	data.VariableGroups["B"].IsSelected = false
+	const toggled = "\t# A=1\n\tA=2\n  \t# B=x\n"
+	if got := render(data); got != toggled {
+		t.Fatalf("rendered %q, want %q", got, toggled)
+	}
+
+	// And back, from the toggled file
+	again := parse(t, toggled)
+	again.VariableGroups["A"].SelectedLineIdx = 0
+	again.VariableGroups["B"].IsSelected = true
+	if got, want := render(again), "\tA=1\n\t# A=2\n  \tB=x\n"; got != want {
+		t.Errorf("rendered %q, want %q", got, want)
+	}
+
+	// A multiline value keeps the indentation of each of its lines
+	data = parse(t, "\tKEY=\"a\n\tb\"\n")
+	data.VariableGroups["KEY"].IsSelected = false
+	if got, want := render(data), "\t# KEY=\"a\n\t# b\"\n"; got != want {
+		t.Errorf("rendered %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,84 @@
+package secrets
+
+import (
+	"math"
+	"regexp"
+
+	"github.com/taha-yassine/sidem/internal/parser"
+)
+
+// Finding is a variable whose value looks like a plaintext secret.
+type Finding struct {
+	Key        string
+	LineNumber int
+	Reason     string // Why the value was flagged
+}
+
+// knownPatterns are well-known secret formats.
+var knownPatterns = []struct {
+	reason string
+	regex  *regexp.Regexp
+}{
+	{"AWS access key ID", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"private key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)},
+	{"GitHub token", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{"Stripe secret key", regexp.MustCompile(`\b[sr]k_live_[A-Za-z0-9]{16,}`)},
+	{"JSON Web Token", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)},
+	{"credentials in URL", regexp.MustCompile(`://[^/\s:@]+:[^/\s@]+@`)},
+}
+
+// tokenRegex matches values made of a single opaque token, the only ones
+// considered for the entropy check (sentences and paths are left alone).
+var tokenRegex = regexp.MustCompile(`^[A-Za-z0-9+/=_\-.]+$`)
+
+const (
+	minEntropyLength = 20  // Shorter values are too short to tell
+	minEntropy       = 4.0 // Bits per character above which a token looks random
+)
+
+// Detect reports whether value looks like a secret, and why.
+func Detect(value string) (string, bool) {
+	for _, pattern := range knownPatterns {
+		if pattern.regex.MatchString(value) {
+			return pattern.reason, true
+		}
+	}
+	if len(value) >= minEntropyLength && tokenRegex.MatchString(value) && Entropy(value) >= minEntropy {
+		return "high-entropy string", true
+	}
+	return "", false
+}
+
+// Entropy returns the Shannon entropy of s, in bits per character.
+func Entropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// Scan returns the variable lines of pd, commented out or not, whose value looks like a secret.
+func Scan(pd *parser.ParsedData) []Finding {
+	var findings []Finding
+	for _, line := range pd.Lines {
+		if line.Type != parser.LineTypeVariable {
+			continue
+		}
+		if reason, ok := Detect(line.Value); ok {
+			findings = append(findings, Finding{Key: line.Key, LineNumber: line.LineNumber, Reason: reason})
+		}
+	}
+	return findings
+}
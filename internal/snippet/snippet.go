@@ -0,0 +1,35 @@
+package snippet
+
+import (
+	"regexp"
+	"strings"
+)
+
+// placeholderRegex matches ${NAME} placeholders in a snippet template.
+var placeholderRegex = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// Placeholders returns the distinct placeholder names of template, in order of appearance.
+func Placeholders(template string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, match := range placeholderRegex.FindAllStringSubmatch(template, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
+
+// Expand replaces the placeholders of template with the given values.
+// Placeholders without a value (or with an empty one) are left as is,
+// so they can still be interpolated later.
+func Expand(template string, values map[string]string) string {
+	return placeholderRegex.ReplaceAllStringFunc(template, func(placeholder string) string {
+		name := strings.TrimSuffix(strings.TrimPrefix(placeholder, "${"), "}")
+		if value, ok := values[name]; ok && value != "" {
+			return value
+		}
+		return placeholder
+	})
+}
@@ -0,0 +1,37 @@
+package snippet
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPlaceholders(t *testing.T) {
+	got := Placeholders("postgres://${USER}:${PASS}@${HOST}/${USER}")
+	want := []string{"USER", "PASS", "HOST"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Placeholders() = %v, want %v", got, want)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		values   map[string]string
+		want     string
+	}{
+		{"all values", "postgres://${USER}:${PASS}@${HOST}", map[string]string{"USER": "app", "PASS": "s3cret", "HOST": "db"}, "postgres://app:s3cret@db"},
+		{"repeated placeholder", "${A}-${A}", map[string]string{"A": "x"}, "x-x"},
+		{"missing value kept", "http://${HOST}:${PORT}", map[string]string{"HOST": "localhost"}, "http://localhost:${PORT}"},
+		{"empty value kept", "${HOST}", map[string]string{"HOST": ""}, "${HOST}"},
+		{"value with placeholder syntax is literal", "${A}", map[string]string{"A": "${B}"}, "${B}"},
+		{"no placeholders", "plain", nil, "plain"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Expand(tt.template, tt.values); got != tt.want {
+				t.Errorf("Expand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
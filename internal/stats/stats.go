@@ -0,0 +1,78 @@
+package stats
+
+import (
+	"unicode/utf8"
+
+	"github.com/taha-yassine/sidem/internal/parser"
+)
+
+// Stats summarizes the variables of a parsed .env file.
+type Stats struct {
+	Variables       int    `json:"variables"`         // Variable lines, active or commented out
+	Active          int    `json:"active"`            // Variable lines not commented out
+	Commented       int    `json:"commented"`         // Variable lines commented out
+	Groups          int    `json:"groups"`            // Distinct keys
+	Duplicates      int    `json:"duplicates"`        // Keys with more than one occurrence
+	Empty           int    `json:"empty"`             // Variable lines with an empty value
+	LongestValue    int    `json:"longest_value"`     // Length in characters of the longest value
+	LongestValueKey string `json:"longest_value_key"` // Key holding the longest value
+}
+
+// Compute returns the statistics of pd.
+func Compute(pd *parser.ParsedData) Stats {
+	s := Stats{Groups: len(pd.GroupOrder)}
+	for _, key := range pd.GroupOrder {
+		group := pd.VariableGroups[key]
+		if len(group.Lines) > 1 {
+			s.Duplicates++
+		}
+		for _, line := range group.Lines {
+			s.Variables++
+			if line.IsCommentedOut {
+				s.Commented++
+			} else {
+				s.Active++
+			}
+			length := utf8.RuneCountInString(line.Value)
+			if length == 0 {
+				s.Empty++
+			}
+			if length > s.LongestValue {
+				s.LongestValue = length
+				s.LongestValueKey = key
+			}
+		}
+	}
+	return s
+}
+
+// SharedValue is a non-empty value held by the active occurrence of several variables.
+type SharedValue struct {
+	Value string
+	Keys  []string
+}
+
+// SharedValues returns the active values shared by more than one variable,
+// in the order they first appear, which often reveals copy-paste mistakes.
+func SharedValues(pd *parser.ParsedData) []SharedValue {
+	keysByValue := make(map[string][]string)
+	var values []string
+	for _, key := range pd.GroupOrder {
+		line := pd.VariableGroups[key].ActiveLine()
+		if line == nil || line.Value == "" {
+			continue
+		}
+		if _, ok := keysByValue[line.Value]; !ok {
+			values = append(values, line.Value)
+		}
+		keysByValue[line.Value] = append(keysByValue[line.Value], key)
+	}
+
+	var shared []SharedValue
+	for _, value := range values {
+		if keys := keysByValue[value]; len(keys) > 1 {
+			shared = append(shared, SharedValue{Value: value, Keys: keys})
+		}
+	}
+	return shared
+}
@@ -0,0 +1,75 @@
+package stats
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/taha-yassine/sidem/internal/parser"
+)
+
+// parse parses content as a .env file, failing the test on error.
+func parse(t *testing.T, content string) *parser.ParsedData {
+	t.Helper()
+	data, err := parser.Parse(strings.NewReader(content), ".env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestCompute(t *testing.T) {
+	data := parse(t, `# Database
+DB_HOST=localhost
+# DB_HOST=db.prod
+# DB_HOST=db.staging
+DB_PASSWORD=
+GREETING="héllo wörld"
+
+# DEBUG=
+PORT=5432
+# just a comment
+`)
+	want := Stats{
+		Variables:       7,
+		Active:          4,
+		Commented:       3,
+		Groups:          5,
+		Duplicates:      1,
+		Empty:           2,
+		LongestValue:    11, // Counted in characters, not bytes
+		LongestValueKey: "GREETING",
+	}
+	if got := Compute(data); got != want {
+		t.Errorf("Compute = %+v, want %+v", got, want)
+	}
+}
+
+func TestComputeEmpty(t *testing.T) {
+	if got := Compute(parse(t, "# nothing here\n\n")); got != (Stats{}) {
+		t.Errorf("Compute = %+v for a file without variables", got)
+	}
+}
+
+func TestSharedValues(t *testing.T) {
+	data := parse(t, `API_URL=https://example.com
+DB_HOST=localhost
+# CACHE_HOST=redis
+CACHE_HOST=localhost
+PUBLIC_URL=https://example.com
+EMPTY=
+ALSO_EMPTY=
+QUEUE_HOST=localhost
+`)
+	want := []SharedValue{
+		{Value: "https://example.com", Keys: []string{"API_URL", "PUBLIC_URL"}},
+		{Value: "localhost", Keys: []string{"DB_HOST", "CACHE_HOST", "QUEUE_HOST"}},
+	}
+	if got := SharedValues(data); !reflect.DeepEqual(got, want) {
+		t.Errorf("SharedValues =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if got := SharedValues(parse(t, "A=1\n# B=1\nC=2\n")); got != nil {
+		t.Errorf("SharedValues without sharing = %+v, want none", got)
+	}
+}
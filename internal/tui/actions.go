@@ -2,10 +2,18 @@ package tui
 
 import (
 	"bufio"
+	"crypto/sha256"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"os/user"
+	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/taha-yassine/sidem/internal/atomicfile"
+	"github.com/taha-yassine/sidem/internal/export"
 	"github.com/taha-yassine/sidem/internal/parser"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -13,7 +21,52 @@ import (
 
 // --- Messages for async operations (used within TUI package) ---
 
-type saveSuccessMsg struct{}
+type saveSuccessMsg struct {
+	autosave bool              // True if the save was triggered by auto-save
+	hash     [sha256.Size]byte // Hash of the content written to the main file
+	lines    int               // Number of lines written
+	changed  int               // Number of variables whose active line changed
+	from     pendingSave       // Buffer state the written content was made from
+}
+
+// saveBlockedMsg is sent instead of saving when the reconstructed content
+// would not parse back to the values held by the model.
+type saveBlockedMsg struct {
+	keys []string // Keys of the variables that would not round-trip
+}
+
+// orphanedLinesMsg is sent instead of saving when some lines can't be
+// represented and would be written as error comments.
+type orphanedLinesMsg struct {
+	count int // Number of orphaned lines
+}
+
+type autosaveMsg struct {
+	gen int // Value of Model.autosaveGen when the auto-save was scheduled
+}
+
+// permissionDeniedMsg is sent when saving fails because the file isn't writable.
+type permissionDeniedMsg struct {
+	path string // Path that could not be written
+}
+
+// savedAsMsg is sent once the buffer has been written to a new path with Save As.
+type savedAsMsg struct {
+	path   string            // New path of the file
+	hash   [sha256.Size]byte // Hash of the written content
+	reload bool              // True if the written content differs from the buffer and must be reloaded
+	from   pendingSave       // Buffer state the written content was made from
+}
+
+type duplicatedMsg struct {
+	path string // Path of the newly written copy
+}
+
+// exportedMsg is sent once the active variables have been written to a file.
+type exportedMsg struct {
+	path  string // Path of the exported file
+	count int    // Number of variables written
+}
 
 type errMsg struct{ err error }
 
@@ -27,82 +80,386 @@ func (e errMsg) Error() string {
 
 // --- Action Commands ---
 
+// normalize applies the key case and spacing policies to data before it is
+// written, and trims its trailing blank lines if asked to.
+func (m *Model) normalize(data *parser.ParsedData) {
+	data.NormalizeKeyCase(m.options.KeyCase)
+	data.NormalizeSpacing(m.options.Spacing)
+	if m.options.TrimTrailingBlank {
+		data.TrimTrailingBlankLines()
+	}
+}
+
+// pendingSave identifies the buffer state a save was prepared from, so the
+// buffer only takes on what was written if it didn't change meanwhile.
+type pendingSave struct {
+	buffer   *parser.ParsedData // Buffer the written copy was made from
+	revision int                // Model.revision when the copy was made
+	stamp    string             // Text of the last-changed annotations, "" if not annotating
+}
+
+// prepareSave returns a copy of the buffer normalized for writing. The buffer
+// itself is left untouched until the save succeeds (see adoptSave), so a save
+// cancelled or blocked by a check doesn't change it.
+func (m *Model) prepareSave() (*parser.ParsedData, pendingSave) {
+	from := pendingSave{buffer: m.parsedData, revision: m.revision}
+	if m.options.AnnotateChanges {
+		from.stamp = fmt.Sprintf("%s by %s", time.Now().Format(time.RFC3339), currentUser())
+	}
+	data := m.parsedData.Clone()
+	m.annotateChanges(data, from.stamp)
+	m.normalize(data)
+	return data, from
+}
+
+// adoptSave applies to the buffer the normalization a successful save wrote,
+// and records which lines are now commented out in the file. It reports false,
+// leaving the buffer as is, if the buffer changed since the save was prepared.
+func (m *Model) adoptSave(from pendingSave) bool {
+	if from.buffer != m.parsedData || from.revision != m.revision {
+		return false
+	}
+	renamed := make(map[string]string)
+	for _, key := range m.parsedData.GroupOrder {
+		if newKey := m.options.KeyCase.Apply(key); newKey != key {
+			renamed[key] = newKey
+		}
+	}
+	m.annotateChanges(m.parsedData, from.stamp)
+	m.normalize(m.parsedData)
+	commitSavedLines(m.parsedData)
+	if len(renamed) > 0 {
+		m.renameKeys(renamed)
+	}
+	return true
+}
+
+// renameKeys makes the state referring to variables by key follow their
+// renaming by the key case policy.
+func (m *Model) renameKeys(renamed map[string]string) {
+	bookmarks := make(map[rune]string, len(m.bookmarks))
+	for letter, key := range m.bookmarks {
+		if newKey, ok := renamed[key]; ok {
+			key = newKey
+		}
+		bookmarks[letter] = key
+	}
+	m.bookmarks = bookmarks
+	m.forgetHistory() // Groups may have been merged
+}
+
+// annotateChanges adds or updates a last-changed comment reading stamp above
+// each variable of data whose active value changed since the file was loaded
+// or last saved. data is the buffer or a copy of it, before normalization.
+func (m *Model) annotateChanges(data *parser.ParsedData, stamp string) {
+	if stamp == "" {
+		return
+	}
+	current := activeValues(data)
+	for _, key := range data.GroupOrder {
+		before, wasActive := m.savedActive[key]
+		value, isActive := current[key]
+		if wasActive != isActive || before != value {
+			data.Annotate(key, stamp)
+		}
+	}
+}
+
+// activeValues returns the active value of each selected variable, by key.
+func activeValues(pd *parser.ParsedData) map[string]string {
+	values := make(map[string]string)
+	for _, v := range export.Active(pd) {
+		values[v.Key] = v.Value
+	}
+	return values
+}
+
+// currentUser returns the name of the user running sidem, for annotations.
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "unknown"
+}
+
 // saveCmd creates a command to save the current state back to the file.
+// Unless m.saveOrphans is set, saving is aborted if some lines are orphaned.
 func (m Model) saveCmd() tea.Cmd {
+	return m.writeCmd(true)
+}
+
+// fastSaveCmd creates a command saving like saveCmd, but without backing up
+// the file first.
+func (m Model) fastSaveCmd() tea.Cmd {
+	return m.writeCmd(false)
+}
+
+// backupPolicy says whether and where files are backed up before being overwritten.
+type backupPolicy struct {
+	enabled bool
+	dir     string // Directory of timestamped backups, "" for a .bak next to the file
+}
+
+// backupPolicy returns how to back up files when backup is requested, following
+// the --no-backup and --backup-dir options.
+func (m *Model) backupPolicy(backup bool) backupPolicy {
+	return backupPolicy{enabled: backup && !m.options.NoBackup, dir: m.options.BackupDir}
+}
+
+// path returns the backup path of filePath made at the given time.
+func (b backupPolicy) path(filePath string, now time.Time) string {
+	if b.dir == "" {
+		return filePath + ".bak"
+	}
+	return filepath.Join(b.dir, filepath.Base(filePath)+"."+now.Format("20060102-150405")+".bak")
+}
+
+// writeCmd implements saveCmd and fastSaveCmd, backing up the file first if backup is set.
+func (m Model) writeCmd(backup bool) tea.Cmd {
+	if m.options.ReadOnly {
+		return func() tea.Msg {
+			return errMsg{fmt.Errorf("%s is open read-only, saving is disabled", m.filePath)}
+		}
+	}
+	changed := m.countChangedVariables()
+	data, from := m.prepareSave()
+	filePath, saveOrphans := m.filePath, m.saveOrphans
+	policy := m.backupPolicy(backup)
 	return func() tea.Msg {
-		err := saveFile(m.filePath, m.parsedData)
+		if orphans := parser.OrphanedLines(data); len(orphans) > 0 && !saveOrphans {
+			return orphanedLinesMsg{count: len(orphans)}
+		}
+		if keys, err := verifyRoundTrip(data); err != nil {
+			return errMsg{err}
+		} else if len(keys) > 0 {
+			return saveBlockedMsg{keys: keys}
+		}
+		hash, err := saveFile(filePath, data, policy)
+		if errors.Is(err, fs.ErrPermission) {
+			return permissionDeniedMsg{path: filePath}
+		} else if err != nil {
+			return errMsg{err}
+		}
+		return saveSuccessMsg{hash: hash, lines: len(data.Lines), changed: changed, from: from}
+	}
+}
+
+// autosaveCmd creates a command to save the current state without user interaction.
+func (m Model) autosaveCmd() tea.Cmd {
+	changed := m.countChangedVariables()
+	data, from := m.prepareSave()
+	filePath := m.filePath
+	policy := m.backupPolicy(true)
+	return func() tea.Msg {
+		if orphans := parser.OrphanedLines(data); len(orphans) > 0 {
+			return errMsg{fmt.Errorf("auto-save skipped: %d line(s) can't be represented, save manually", len(orphans))}
+		}
+		if keys, err := verifyRoundTrip(data); err != nil {
+			return errMsg{fmt.Errorf("auto-save failed: %w", err)}
+		} else if len(keys) > 0 {
+			return saveBlockedMsg{keys: keys}
+		}
+		hash, err := saveFile(filePath, data, policy)
 		if err != nil {
+			return errMsg{fmt.Errorf("auto-save failed: %w", err)}
+		}
+		return saveSuccessMsg{autosave: true, hash: hash, lines: len(data.Lines), changed: changed, from: from}
+	}
+}
+
+// saveAsCmd creates a command writing the lines of the current file to a new path.
+// Lines coming from other source files are left out. Saving an example file to a
+// regular one keeps the example values as commented references (see exampleToEnv).
+func (m Model) saveAsCmd(target string) tea.Cmd {
+	fromExample := isExampleFile(m.filePath) && !isExampleFile(target)
+	data, from := m.prepareSave()
+	_, linesBySource := groupLinesBySource(m.filePath, data)
+	lines := linesBySource[m.filePath]
+	policy := m.backupPolicy(true)
+	return func() tea.Msg {
+		if keys, err := verifyRoundTrip(data); err != nil {
 			return errMsg{err}
+		} else if len(keys) > 0 {
+			return saveBlockedMsg{keys: keys}
+		}
+		content := parser.RenderLines(lines, data)
+		if fromExample {
+			var err error
+			if content, err = exampleToEnv(content); err != nil {
+				return errMsg{err}
+			}
 		}
-		return saveSuccessMsg{}
+		err := writeContent(target, content, policy)
+		if errors.Is(err, fs.ErrPermission) {
+			return permissionDeniedMsg{path: target}
+		} else if err != nil {
+			return errMsg{err}
+		}
+		return savedAsMsg{path: target, hash: sha256.Sum256([]byte(content)), reload: fromExample, from: from}
 	}
 }
 
-// saveFile reconstructs and saves the .env file.
-func saveFile(filePath string, data *parser.ParsedData) error {
-	// 1. Create a backup
-	backupPath := filePath + ".bak"
-	if err := backupFile(filePath, backupPath); err != nil {
-		// Non-fatal error, but log it or notify user?
-		// For now, proceed even if backup fails, but return the backup error
-		// return fmt.Errorf("failed to create backup %s: %w", backupPath, err)
-		// Let's log it and continue
-		fmt.Fprintf(os.Stderr, "Warning: Failed to create backup %s: %v\n", backupPath, err)
-	}
-
-	// 2. Prepare the new content
+// duplicateCmd creates a command writing a copy of the current buffer to a
+// sibling file named after the given environment (e.g. .env -> .env.staging).
+func (m Model) duplicateCmd(envName string) tea.Cmd {
+	source, target := m.filePath, duplicatePath(m.filePath, envName)
+	data := m.parsedData.Clone() // The buffer may change while the copy is written
+	return func() tea.Msg {
+		if err := duplicateFile(source, target, data); err != nil {
+			return errMsg{err}
+		}
+		return duplicatedMsg{path: target}
+	}
+}
+
+// exportCmd creates a command writing the active variables to target.
+func (m Model) exportCmd(target string) tea.Cmd {
+	data := m.parsedData.Clone() // The buffer may change while the export is written
+	return func() tea.Msg {
+		if err := exportActiveVars(target, data); err != nil {
+			return errMsg{err}
+		}
+		return exportedMsg{path: target, count: len(export.Active(data))}
+	}
+}
+
+// isLoadedFile reports whether path is the open file or one of the files it
+// includes, following symlinks.
+func (m *Model) isLoadedFile(path string) bool {
+	for _, loaded := range append([]string{m.filePath}, m.parsedData.SourceFiles()...) {
+		if samePath(path, loaded) {
+			return true
+		}
+	}
+	return false
+}
+
+// samePath reports whether a and b name the same file, or the same path if
+// one of them doesn't exist yet.
+func samePath(a, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	if errA == nil && errB == nil {
+		return os.SameFile(infoA, infoB)
+	}
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
+
+// exportActiveVars writes the active value of each selected variable to path as
+// KEY=value lines, leaving out comments and commented-out alternatives, so the
+// file can be sourced or passed to docker --env-file.
+func exportActiveVars(path string, data *parser.ParsedData) error {
 	var builder strings.Builder
-	for _, line := range data.Lines {
-		switch line.Type {
-		case parser.LineTypeBlank, parser.LineTypeComment:
-			builder.WriteString(line.OriginalContent)
-			builder.WriteString("\n")
-		case parser.LineTypeVariable:
-			group, ok := data.VariableGroups[line.Key]
-			if !ok {
-				// Should not happen if parsing was correct, but handle defensively
-				builder.WriteString("# Error: Orphaned variable line! -> " + line.OriginalContent)
-				builder.WriteString("\n")
-				continue
-			}
+	if err := export.Write(&builder, export.FormatEnv, export.Active(data)); err != nil {
+		return err
+	}
+	return writeContent(path, builder.String(), backupPolicy{})
+}
 
-			// Find the index of this specific line within its group
-			lineIndexInGroup := -1
-			for i, groupLine := range group.Lines {
-				if groupLine == line { // Compare pointers
-					lineIndexInGroup = i
-					break
-				}
-			}
+// duplicatePath returns the sibling path of filePath for the given environment name.
+func duplicatePath(filePath, envName string) string {
+	return filepath.Join(filepath.Dir(filePath), filepath.Base(filePath)+"."+strings.TrimPrefix(envName, "."))
+}
 
-			if lineIndexInGroup == -1 {
-				// Should also not happen
-				builder.WriteString("# Error: Could not find line in its group! -> " + line.OriginalContent)
-				builder.WriteString("\n")
-				continue
-			}
+// duplicateFile writes the reconstructed buffer to a new file with the
+// permissions of source, refusing to overwrite an existing one.
+func duplicateFile(source, target string, data *parser.ParsedData) error {
+	content := parser.RenderLines(data.Lines, data)
+	mode := os.FileMode(0644) // The buffer wasn't saved yet
+	if info, err := os.Stat(source); err == nil {
+		mode = info.Mode().Perm()
+	}
+	file, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		if os.IsExist(err) {
+			return fmt.Errorf("file %s already exists", target)
+		}
+		return fmt.Errorf("failed to create file %s: %w", target, err)
+	}
+	defer file.Close()
+	if err := file.Chmod(mode); err != nil { // Not restricted by the umask
+		return fmt.Errorf("failed to set permissions of %s: %w", target, err)
+	}
 
-			newLineContent := reconstructVariableLine(line, group, lineIndexInGroup)
-			builder.WriteString(newLineContent)
-			builder.WriteString("\n")
+	if _, err := file.WriteString(content); err != nil {
+		return fmt.Errorf("failed to write to file %s: %w", target, err)
+	}
+	return nil
+}
 
-		default:
-			// Preserve unknown line types?
-			builder.WriteString(line.OriginalContent)
-			builder.WriteString("\n")
+// saveFile reconstructs and saves the .env file.
+// Each line is written back to the file it was read from, so variables coming
+// from other source files never end up in filePath.
+// Each file is backed up first according to backup.
+// It returns the hash of the content written to filePath.
+func saveFile(filePath string, data *parser.ParsedData, backup backupPolicy) ([sha256.Size]byte, error) {
+	var hash [sha256.Size]byte
+	sources, linesBySource := groupLinesBySource(filePath, data)
+	for _, source := range sources {
+		content, err := saveSourceFile(source, linesBySource[source], data, backup)
+		if err != nil {
+			return hash, err
+		}
+		if source == filePath {
+			hash = sha256.Sum256([]byte(content))
+		}
+	}
+	return hash, nil
+}
+
+// groupLinesBySource splits the parsed lines by the file they belong to.
+// Lines without a source file are attributed to filePath.
+func groupLinesBySource(filePath string, data *parser.ParsedData) ([]string, map[string][]*parser.Line) {
+	sources := []string{}
+	linesBySource := make(map[string][]*parser.Line)
+	for _, line := range data.Lines {
+		source := line.SourceFile
+		if source == "" {
+			source = filePath
+		}
+		if _, ok := linesBySource[source]; !ok {
+			sources = append(sources, source)
 		}
+		linesBySource[source] = append(linesBySource[source], line)
+	}
+	if len(sources) == 0 {
+		// Empty file, still write it
+		sources = append(sources, filePath)
+	}
+	return sources, linesBySource
+}
+
+// saveSourceFile writes the given lines to a single source file and returns the written content.
+func saveSourceFile(filePath string, lines []*parser.Line, data *parser.ParsedData, backup backupPolicy) (string, error) {
+	content := parser.RenderLines(lines, data)
+	if err := writeContent(filePath, content, backup); err != nil {
+		return "", err
 	}
+	return content, nil
+}
 
-	// 3. Write the new content, overwriting the original file
-	// Use WriteFile for atomicity (creates temp file, then renames)
-	// Need to remove trailing newline potentially added by loop if last line wasn't blank
-	content := builder.String()
-	// Ensure file ends with a newline as per custom instructions
-	if !strings.HasSuffix(content, "\n") {
-		content += "\n"
+// writeContent overwrites filePath with content, backing it up first if enabled.
+func writeContent(filePath, content string, backup backupPolicy) error {
+	// 1. Create a backup, unless fast saving or disabled
+	if backup.enabled {
+		backupPath := backup.path(filePath, time.Now())
+		if err := backupFile(filePath, backupPath); err != nil {
+			// Non-fatal error, but log it or notify user?
+			// For now, proceed even if backup fails, but return the backup error
+			// return fmt.Errorf("failed to create backup %s: %w", backupPath, err)
+			// Let's log it and continue
+			fmt.Fprintf(os.Stderr, "Warning: Failed to create backup %s: %v\n", backupPath, err)
+		}
 	}
 
-	err := os.WriteFile(filePath, []byte(content), 0644) // Use default permissions
+	// 2. Write the new content, replacing the original file atomically
+	// and keeping its permissions
+	err := atomicfile.Write(filePath, []byte(content))
 	if err != nil {
 		return fmt.Errorf("failed to write to file %s: %w", filePath, err)
 	}
@@ -110,49 +467,112 @@ func saveFile(filePath string, data *parser.ParsedData) error {
 	return nil
 }
 
-// reconstructVariableLine determines the correct content for a variable line based on TUI state.
-func reconstructVariableLine(line *parser.Line, group *parser.VariableGroup, lineIndexInGroup int) string {
-	// Reconstruct the original Key=Value part, removing any initial comment marker
-	// We stored Key and Value separately, need original spacing/quoting?
-	// Simplification: Assume standard KEY=VALUE format is okay for reconstruction.
-	// Let's try to use OriginalContent and add/remove '#' carefully.
-
-	originalContent := line.OriginalContent
-	hasPrefix := strings.HasPrefix(strings.TrimSpace(originalContent), "#")
-
-	shouldBeActive := group.IsSelected && group.SelectedLineIdx == lineIndexInGroup
-
-	if shouldBeActive {
-		// Needs to be uncommented
-		if hasPrefix {
-			// Find the first '#' and remove it and any space after it
-			idx := strings.Index(originalContent, "#")
-			if idx != -1 {
-				prefix := originalContent[:idx]
-				suffix := originalContent[idx+1:]
-				// Remove leading space from suffix if present
-				suffix = strings.TrimPrefix(suffix, " ")
-				return prefix + suffix
-			} else {
-				// '#' wasn't found where expected? Return original.
-				return originalContent
-			}
-		} else {
-			// Already uncommented, return as is
-			return originalContent
+// isExampleFile reports whether path looks like a template such as .env.example.
+func isExampleFile(path string) bool {
+	base := strings.ToLower(filepath.Base(path))
+	return strings.Contains(base, ".example") || strings.Contains(base, ".sample") || strings.Contains(base, ".template")
+}
+
+// exampleToEnv turns the content of an example file into a fresh .env:
+// every active variable is kept as a commented reference line, followed
+// by an active line with an empty value for the user to fill in.
+func exampleToEnv(content string) (string, error) {
+	pd, err := parser.Parse(strings.NewReader(content), "")
+	if err != nil {
+		return "", err
+	}
+
+	var builder strings.Builder
+	for _, line := range pd.Lines {
+		if line.Type != parser.LineTypeVariable || line.IsCommentedOut {
+			builder.WriteString(line.OriginalContent)
+			builder.WriteString("\n")
+			continue
 		}
-	} else {
-		// Needs to be commented out
-		if hasPrefix {
-			// Already commented, return as is
-			return originalContent
-		} else {
-			// Add '# ' prefix, preserving original indentation
-			trimmedPrefix := strings.TrimLeft(originalContent, " \t")
-			indentation := originalContent[:len(originalContent)-len(trimmedPrefix)]
-			return indentation + "# " + trimmedPrefix
+		trimmed := strings.TrimLeft(line.OriginalContent, " \t")
+		indentation := line.OriginalContent[:len(line.OriginalContent)-len(trimmed)]
+		builder.WriteString(indentation + "# " + trimmed + "\n")
+		builder.WriteString(indentation + line.Key + "=\n")
+	}
+	return builder.String(), nil
+}
+
+// fileHasHash reports whether the current content of filePath hashes to hash.
+func fileHasHash(filePath string, hash [sha256.Size]byte) bool {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return false
+	}
+	return sha256.Sum256(content) == hash
+}
+
+// countChangedVariables returns the number of variables whose active value,
+// or whether one is active, changed since the file was loaded or last saved.
+// Variables no longer declared count if they were active.
+func (m *Model) countChangedVariables() int {
+	changed := 0
+	current := activeValues(m.parsedData)
+	for _, key := range m.parsedData.GroupOrder {
+		before, wasActive := m.savedActive[key]
+		value, isActive := current[key]
+		if wasActive != isActive || before != value {
+			changed++
+		}
+	}
+	for key := range m.savedActive {
+		if _, declared := m.parsedData.VariableGroups[key]; !declared {
+			changed++
 		}
 	}
+	return changed
+}
+
+// commitSavedLines updates the variable lines' content to what was just written,
+// so the next save starts from the on-disk state.
+func commitSavedLines(data *parser.ParsedData) {
+	for _, key := range data.GroupOrder {
+		group := data.VariableGroups[key]
+		for i, line := range group.Lines {
+			line.OriginalContent = data.ReconstructVariableLine(line, group, i)
+			line.IsCommentedOut = !(group.IsSelected && group.SelectedLineIdx == i)
+		}
+	}
+	data.DetectConflicts()
+}
+
+// verifyRoundTrip reparses the content that saving would produce and compares
+// it against the model. It returns the keys whose values or selection would
+// change meaning once written.
+func verifyRoundTrip(data *parser.ParsedData) ([]string, error) {
+	content := parser.RenderLines(data.Lines, data)
+	reparsed, err := parser.ParseWithOptions(strings.NewReader(content), "", parser.Options{CommentMarker: data.CommentMarker})
+	if err != nil {
+		return nil, fmt.Errorf("reconstructed content does not parse: %w", err)
+	}
+
+	var mismatches []string
+	for _, key := range data.GroupOrder {
+		if !groupsMatch(data.VariableGroups[key], reparsed.VariableGroups[key]) {
+			mismatches = append(mismatches, key)
+		}
+	}
+	return mismatches, nil
+}
+
+// groupsMatch reports whether two groups hold the same values and the same active value.
+func groupsMatch(want, got *parser.VariableGroup) bool {
+	if want == nil || got == nil || len(want.Lines) != len(got.Lines) {
+		return false
+	}
+	for i := range want.Lines {
+		if want.Lines[i].Value != got.Lines[i].Value {
+			return false
+		}
+	}
+	if want.IsSelected != got.IsSelected {
+		return false
+	}
+	return !want.IsSelected || want.SelectedLineIdx == got.SelectedLineIdx
 }
 
 // backupFile creates a backup of the source file.
@@ -166,13 +586,24 @@ func backupFile(src, dst string) error {
 		return fmt.Errorf("failed to open source file %s for backup: %w", src, err)
 	}
 	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat source file %s for backup: %w", src, err)
+	}
 
-	// Create destination file
-	out, err := os.Create(dst)
+	// Create destination file, and its directory for --backup-dir, no more
+	// readable than the source since it holds the same secrets
+	if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		return fmt.Errorf("failed to create backup directory %s: %w", filepath.Dir(dst), err)
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
 	if err != nil {
 		return fmt.Errorf("failed to create backup file %s: %w", dst, err)
 	}
 	defer out.Close()
+	if err := out.Chmod(info.Mode().Perm()); err != nil { // An existing backup keeps its mode otherwise
+		return fmt.Errorf("failed to set permissions of backup file %s: %w", dst, err)
+	}
 
 	// Copy contents using buffered I/O
 	reader := bufio.NewReader(in)
@@ -0,0 +1,90 @@
+package tui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// bookmarkMode is the bookmark key waiting for its letter.
+type bookmarkMode int
+
+const (
+	bookmarkNone bookmarkMode = iota
+	bookmarkSet               // 'm' pressed, the letter names the new bookmark
+	bookmarkJump              // '\'' pressed, the letter names the bookmark to jump to
+)
+
+// focusedGroupKey returns the key of the group under the cursor, or "" on a file header.
+func (m *Model) focusedGroupKey() string {
+	listItems := m.getCurrentListItems()
+	if m.cursor < 0 || m.cursor >= len(listItems) {
+		return ""
+	}
+	item := listItems[m.cursor]
+	if item.isFileHeader || item.groupIndex < 0 || item.groupIndex >= len(m.parsedData.GroupOrder) {
+		return ""
+	}
+	return m.parsedData.GroupOrder[item.groupIndex]
+}
+
+// startBookmark waits for the letter of a bookmark to set or jump to.
+func (m Model) startBookmark(mode bookmarkMode) Model {
+	m.pendingBookmark = mode
+	if mode == bookmarkSet {
+		m.statusMessage = "Bookmark: press a letter."
+	} else {
+		m.statusMessage = "Jump to bookmark: press a letter."
+	}
+	return m
+}
+
+// handleBookmark handles the letter following 'm' or '\”.
+func (m Model) handleBookmark(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	mode := m.pendingBookmark
+	m.pendingBookmark = bookmarkNone
+
+	runes := msg.Runes
+	if msg.Type != tea.KeyRunes || len(runes) != 1 || !isBookmarkLetter(runes[0]) {
+		m.statusMessage = ""
+		return m, nil // Any other key cancels
+	}
+	letter := runes[0]
+
+	if mode == bookmarkSet {
+		key := m.focusedGroupKey()
+		if key == "" {
+			m.statusMessage = "Focus a variable to bookmark it."
+			return m, nil
+		}
+		bookmarks := make(map[rune]string, len(m.bookmarks)+1)
+		for l, k := range m.bookmarks {
+			bookmarks[l] = k
+		}
+		bookmarks[letter] = key
+		m.bookmarks = bookmarks
+		cmd := m.setStatus(fmt.Sprintf("Bookmarked %s as '%c'.", key, letter))
+		return m, cmd
+	}
+
+	key, ok := m.bookmarks[letter]
+	if !ok {
+		m.statusMessage = fmt.Sprintf("Error: no bookmark '%c'.", letter)
+		return m, nil
+	}
+	for i, item := range m.getCurrentListItems() {
+		if item.isGroupHeader && !item.isFileHeader && m.parsedData.GroupOrder[item.groupIndex] == key {
+			m.cursor = i
+			m.ensureCursorVisible()
+			m.updateViewportContent()
+			return m, nil
+		}
+	}
+	m.statusMessage = fmt.Sprintf("Error: bookmarked variable %s no longer exists.", key)
+	return m, nil
+}
+
+// isBookmarkLetter reports whether r can name a bookmark.
+func isBookmarkLetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
@@ -0,0 +1,67 @@
+package tui
+
+import (
+	"os"
+	"testing"
+)
+
+// headerIndex returns the list index of the header of key, or -1.
+func headerIndex(m Model, key string) int {
+	for i, item := range m.getCurrentListItems() {
+		if item.isGroupHeader && !item.isFileHeader && m.parsedData.GroupOrder[item.groupIndex] == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestBookmarks(t *testing.T) {
+	m := newTestModel(t, "A=1\nB=2\nC=3\n", Options{})
+	m.cursor = headerIndex(m, "B") + 1 // B's value
+	before := press(m, "m", "a")
+	m = before
+	if got := m.bookmarks['a']; got != "B" {
+		t.Fatalf("bookmark 'a' is %q, want B", got)
+	}
+
+	m.cursor = 0
+	if m = press(m, "'", "a"); m.cursor != headerIndex(m, "B") {
+		t.Errorf("jumped to %d, want B's header at %d", m.cursor, headerIndex(m, "B"))
+	}
+	if m = press(m, "'", "b"); m.statusMessage != "Error: no bookmark 'b'." {
+		t.Errorf("status %q after jumping to an unset bookmark", m.statusMessage)
+	}
+
+	// Bookmarks follow the variable when the list is reordered
+	if err := os.WriteFile(m.filePath, []byte("C=3\nA=1\nX=0\nB=2\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	updated, _ := m.Update(m.reloadFileCmd(m.filePath)())
+	m = updated.(Model)
+	m.cursor = 0
+	if m = press(m, "'", "a"); m.cursor != headerIndex(m, "B") || m.cursor == 0 {
+		t.Errorf("jumped to %d after reordering, want B's header at %d", m.cursor, headerIndex(m, "B"))
+	}
+
+	// Setting another bookmark leaves the previous model's alone
+	m.cursor = headerIndex(m, "C")
+	m = press(m, "m", "a")
+	if m.bookmarks['a'] != "C" || before.bookmarks['a'] != "B" {
+		t.Errorf("bookmark 'a' is %q, %q in the previous model", m.bookmarks['a'], before.bookmarks['a'])
+	}
+
+	// Any other key cancels
+	m = press(m, "m", "esc")
+	if m.pendingBookmark != bookmarkNone || len(m.bookmarks) != 1 {
+		t.Errorf("esc left bookmarks %v, pending %v", m.bookmarks, m.pendingBookmark)
+	}
+
+	if err := os.WriteFile(m.filePath, []byte("A=1\nB=2\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	updated, _ = m.Update(m.reloadFileCmd(m.filePath)())
+	m = press(updated.(Model), "'", "a")
+	if want := "Error: bookmarked variable C no longer exists."; m.statusMessage != want {
+		t.Errorf("status %q, want %q", m.statusMessage, want)
+	}
+}
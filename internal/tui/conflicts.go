@@ -0,0 +1,46 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// isConflicted reports whether key is uncommented on several lines of the file.
+func (m *Model) isConflicted(key string) bool {
+	return slices.Contains(m.parsedData.Conflicts, key)
+}
+
+// cycleConflict makes the next line uncommented in the file the active one for
+// the focused conflicted group. The other lines get commented out on save.
+func (m Model) cycleConflict() (Model, tea.Cmd) {
+	if m.refuseReadOnly() {
+		return m, nil
+	}
+	key := m.focusedGroupKey()
+	if key == "" || !m.isConflicted(key) {
+		m.statusMessage = "Focus a variable uncommented on several lines to cycle them."
+		return m, nil
+	}
+	group := m.parsedData.VariableGroups[key]
+	next := -1
+	for i := range group.Lines {
+		idx := (group.SelectedLineIdx + 1 + i) % len(group.Lines)
+		if !group.Lines[idx].IsCommentedOut {
+			next = idx
+			break
+		}
+	}
+	if next < 0 {
+		return m, nil
+	}
+
+	m.pushUndo(m.snapshot(key))
+	group.IsSelected = true
+	group.SelectedLineIdx = next
+	m.updateViewportContent()
+	cmd := m.setStatus(fmt.Sprintf("%s now uses line %d; its other uncommented lines are commented out on save.",
+		key, group.Lines[next].LineNumber))
+	return m, tea.Batch(cmd, m.flashActive(key), m.markModified())
+}
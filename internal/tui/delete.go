@@ -0,0 +1,73 @@
+package tui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pendingDelete is a removal waiting for confirmation.
+type pendingDelete struct {
+	key   string // Variable to remove from
+	index int    // Occurrence to remove, -1 to remove the whole variable
+}
+
+// openDeletePrompt asks for confirmation before removing the focused value
+// line, or the whole variable when the cursor is on its header.
+func (m Model) openDeletePrompt() Model {
+	if m.refuseReadOnly() {
+		return m
+	}
+	key := m.focusedGroupKey()
+	if key == "" {
+		m.statusMessage = "Focus a variable to delete it."
+		return m
+	}
+	index := -1
+	if item := m.getCurrentListItems()[m.cursor]; !item.isGroupHeader {
+		index = item.valueIndex
+	}
+	m.pendingDelete = &pendingDelete{key: key, index: index}
+	return m
+}
+
+// deletePrompt returns the confirmation question of the pending removal.
+func (m *Model) deletePrompt() string {
+	d := m.pendingDelete
+	group := m.parsedData.VariableGroups[d.key]
+	if d.index == -1 {
+		return fmt.Sprintf("Delete %s and its %d occurrence(s)? ([Y]es/[N]o)", d.key, len(group.Lines))
+	}
+	return fmt.Sprintf("Delete this occurrence of %s (line %d)? ([Y]es/[N]o)", d.key, group.Lines[d.index].LineNumber)
+}
+
+// handleDeletePrompt handles key presses while a removal waits for confirmation.
+func (m Model) handleDeletePrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y":
+		d := *m.pendingDelete
+		m.pendingDelete = nil
+		entry := m.snapshotLayout(d.key)
+		var err error
+		if d.index == -1 {
+			err = m.parsedData.RemoveVariable(d.key)
+			m.statusMessage = fmt.Sprintf("Deleted %s.", d.key)
+		} else {
+			err = m.parsedData.RemoveOccurrence(d.key, d.index)
+			m.statusMessage = fmt.Sprintf("Deleted an occurrence of %s.", d.key)
+		}
+		if err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", err)
+			return m, nil
+		}
+		m.pushUndo(entry)
+		m.clampCursor()
+		m.ensureCursorVisible()
+		m.updateViewportContent()
+		return m, m.markModified()
+	case "n", "N", "esc":
+		m.pendingDelete = nil
+		return m, nil
+	}
+	return m, nil
+}
@@ -0,0 +1,104 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// detailMinWidth is the terminal width below which the detail panel is hidden.
+const detailMinWidth = 100
+
+// toggleDetail shows or hides the detail panel next to the list.
+func (m Model) toggleDetail() Model {
+	m.showDetail = !m.showDetail
+	if m.showDetail && m.width < detailMinWidth {
+		m.statusMessage = fmt.Sprintf("Detail panel shown once the terminal is at least %d columns wide.", detailMinWidth)
+	}
+	return m
+}
+
+// detailVisible reports whether the detail panel is laid out next to the list.
+func (m *Model) detailVisible() bool {
+	return m.showDetail && m.width >= detailMinWidth
+}
+
+// detailWidth returns the width of the detail panel, border included.
+func (m *Model) detailWidth() int {
+	return m.width * 2 / 5
+}
+
+// listWidth returns the width available to the list.
+func (m *Model) listWidth() int {
+	if m.detailVisible() {
+		return m.width - m.detailWidth()
+	}
+	return m.width
+}
+
+// detailLines assembles the detail panel content for the group with the given key:
+// its key and type, every occurrence with its source line number and comment,
+// then the comment block describing it.
+func (m *Model) detailLines(key string) []string {
+	group, ok := m.parsedData.VariableGroups[key]
+	if !ok {
+		return nil
+	}
+	groupIndex := slices.Index(m.parsedData.GroupOrder, key)
+
+	lines := []string{m.styles.KeyStyle.Render(key)}
+	if group.IsSelected && group.SelectedLineIdx >= 0 && group.SelectedLineIdx < len(group.Lines) {
+		lines = append(lines, "Type: "+classifyValue(group.Lines[group.SelectedLineIdx].Value))
+	} else {
+		lines = append(lines, "Type: "+classifyValue("")+" (inactive)")
+	}
+
+	lines = append(lines, "", fmt.Sprintf("Occurrences (%d):", len(group.Lines)))
+	for i, line := range group.Lines {
+		marker := iconRadioOff
+		if group.IsSelected && i == group.SelectedLineIdx {
+			marker = iconRadioOn
+		}
+		value := line.Value
+		if m.isMasked(ListItem{groupIndex: groupIndex, value: line.Value}) {
+			value = iconMasked
+		}
+		entry := fmt.Sprintf("%s L%d %s", marker, line.LineNumber, value)
+		if line.Comment != "" {
+			entry += m.styles.DisabledLine.Render("  # " + line.Comment)
+		}
+		lines = append(lines, entry)
+	}
+
+	if description := m.parsedData.Description(key); len(description) > 0 {
+		lines = append(lines, "")
+		lines = append(lines, description...)
+	}
+	return lines
+}
+
+// renderDetail renders the detail panel of the focused group, height rows tall.
+func (m *Model) renderDetail(height int) string {
+	width := m.detailWidth()
+	style := lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder(), false, false, false, true).
+		BorderForeground(m.styles.Footer.GetForeground()).
+		PaddingLeft(1)
+	inner := max(0, width-style.GetHorizontalFrameSize()) // Room for text
+
+	lines := m.detailLines(m.focusedGroupKey())
+	if lines == nil {
+		lines = []string{m.styles.DisabledLine.Render("Focus a variable to see its details.")}
+	}
+	for i, line := range lines {
+		lines[i] = ansi.Truncate(line, inner, "…")
+	}
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+
+	return style.Width(width - style.GetBorderLeftSize()).Height(height).MaxHeight(height).Render(strings.Join(lines, "\n"))
+}
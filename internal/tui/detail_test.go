@@ -0,0 +1,64 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// plainDetailLines returns the detail panel lines of key without styling.
+func plainDetailLines(m Model, key string) []string {
+	lines := m.detailLines(key)
+	for i, line := range lines {
+		lines[i] = ansi.Strip(line)
+	}
+	return lines
+}
+
+func TestDetailLines(t *testing.T) {
+	m := newTestModel(t, "# Database host\n# Overridden in production\nDB_HOST=localhost # dev\n# DB_HOST=db.prod\n# PORT=5432\nAPI_TOKEN=hunter2hunter2\n", Options{MaskSecrets: true})
+
+	want := []string{
+		"DB_HOST",
+		"Type: str",
+		"",
+		"Occurrences (2):",
+		iconRadioOn + " L3 localhost  # dev",
+		iconRadioOff + " L4 db.prod",
+		"",
+		"Database host",
+		"Overridden in production",
+	}
+	if got := plainDetailLines(m, "DB_HOST"); !slices.Equal(got, want) {
+		t.Errorf("DB_HOST details\n%q\nwant\n%q", got, want)
+	}
+
+	if got := plainDetailLines(m, "PORT"); len(got) < 5 || got[1] != "Type: empty (inactive)" || got[4] != iconRadioOff+" L5 5432" {
+		t.Errorf("PORT details %q, want an inactive variable", got)
+	}
+	if got := strings.Join(plainDetailLines(m, "API_TOKEN"), "\n"); strings.Contains(got, "hunter2") || !strings.Contains(got, iconMasked) {
+		t.Errorf("API_TOKEN details show the secret:\n%s", got)
+	}
+	if got := m.detailLines("MISSING"); got != nil {
+		t.Errorf("details of an undeclared key %q, want none", got)
+	}
+}
+
+func TestDetailPanelNeedsWidth(t *testing.T) {
+	m := press(newTestModel(t, "# Database host\nDB_HOST=localhost\n", Options{}), "I")
+	if !strings.Contains(m.View(), "Occurrences (1):") {
+		t.Errorf("detail panel not shown at width %d:\n%s", m.width, m.View())
+	}
+
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: detailMinWidth - 1, Height: 40})
+	m = updated.(Model)
+	if view := m.View(); strings.Contains(view, "Occurrences (1):") {
+		t.Errorf("detail panel shown at width %d:\n%s", m.width, view)
+	}
+	if m = press(m, "I", "I"); !strings.Contains(m.statusMessage, "at least 100 columns") {
+		t.Errorf("status %q, want a hint about the width", m.statusMessage)
+	}
+}
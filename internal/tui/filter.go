@@ -0,0 +1,54 @@
+package tui
+
+// openFilter shows the footer input filtering the list by key, starting from
+// the current filter.
+func (m Model) openFilter() Model {
+	return m.openInput(inputFilter, "/", "key", m.filter)
+}
+
+// applyFilter lists only the groups whose key matches query and moves the
+// cursor to the first match.
+func (m Model) applyFilter(query string) Model {
+	m.filter = query
+	m.cursor = 0
+	m.viewport.GotoTop()
+	m.updateViewportContent()
+	return m
+}
+
+// clearFilter restores the full list, keeping the cursor on the focused group.
+func (m Model) clearFilter() Model {
+	key := m.focusedGroupKey()
+	m.filter = ""
+	m.cursor = 0
+	for i, item := range m.getCurrentListItems() {
+		if item.isGroupHeader && m.parsedData.GroupOrder[item.groupIndex] == key {
+			m.cursor = i
+			break
+		}
+	}
+	m.updateViewportContent()
+	m.ensureCursorVisible()
+	return m
+}
+
+// matchesFilter reports whether the group key is listed under the current
+// filter: its characters appear in order in the key, case-insensitively.
+func (m *Model) matchesFilter(key string) bool {
+	if m.filter == "" {
+		return true
+	}
+	_, ok := fuzzyMatch(m.filter, key)
+	return ok
+}
+
+// filterMatches returns the number of groups listed under the current filter.
+func (m *Model) filterMatches() int {
+	count := 0
+	for _, key := range m.parsedData.GroupOrder {
+		if m.matchesFilter(key) {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,89 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/taha-yassine/sidem/internal/parser"
+)
+
+// newTestModel writes content to a .env file in a temporary directory and
+// opens it in a sized model.
+func newTestModel(t *testing.T, content string, opts Options) Model {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	data, err := parser.ParseFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := InitialModel(path, data, nil, opts)
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	return updated.(Model)
+}
+
+// namedKeys are the keys press sends by name rather than typing them.
+var namedKeys = map[string]tea.KeyType{
+	"enter":  tea.KeyEnter,
+	"esc":    tea.KeyEsc,
+	"down":   tea.KeyDown,
+	"ctrl+k": tea.KeyCtrlK,
+	"ctrl+l": tea.KeyCtrlL,
+	"ctrl+o": tea.KeyCtrlO,
+	"ctrl+r": tea.KeyCtrlR,
+	"ctrl+s": tea.KeyCtrlS,
+	"ctrl+u": tea.KeyCtrlU,
+}
+
+// press sends each key to the model in turn, ignoring the returned commands.
+// Keys are named as in tea.KeyMsg.String(), any other string is typed as runes.
+func press(m Model, keys ...string) Model {
+	for _, key := range keys {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+		if keyType, ok := namedKeys[key]; ok {
+			msg = tea.KeyMsg{Type: keyType}
+		}
+		updated, _ := m.Update(msg)
+		m = updated.(Model)
+	}
+	return m
+}
+
+// parseContent parses content as a .env file, failing the test on error.
+func parseContent(t *testing.T, content string) *parser.ParsedData {
+	t.Helper()
+	data, err := parser.Parse(strings.NewReader(content), ".env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// activeValue returns the value of the active line of key, or "" if inactive.
+func activeValue(t *testing.T, m Model, key string) string {
+	t.Helper()
+	group, ok := m.parsedData.VariableGroups[key]
+	if !ok {
+		t.Fatalf("%s is not declared", key)
+	}
+	if line := group.ActiveLine(); line != nil {
+		return line.Value
+	}
+	return ""
+}
+
+// hasLine reports whether view has a line reading want, ignoring trailing spaces.
+func hasLine(view, want string) bool {
+	for _, line := range strings.Split(view, "\n") {
+		if strings.TrimRight(line, " ") == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,285 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/taha-yassine/sidem/internal/parser"
+	"github.com/taha-yassine/sidem/internal/snippet"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// inputKind identifies what the footer text input is currently collecting.
+type inputKind int
+
+const (
+	inputNone               inputKind = iota
+	inputDuplicateSuffix              // Environment name for the duplicated file
+	inputSaveAsPath                   // Destination path for Save As
+	inputExportPath                   // Destination path of the exported active variables
+	inputSnippetName                  // Name of the snippet to insert
+	inputSnippetPlaceholder           // Value of the next snippet placeholder
+	inputGotoLine                     // File line number to jump to
+	inputOccurrenceValue              // New value of the occurrence highlighted in the occurrences table
+	inputValue                        // New value of the focused value line, edited in place
+	inputNewKey                       // Key of the variable to add
+	inputNewValue                     // Value of the variable to add
+	inputFilter                       // Query filtering the list by key
+)
+
+// newTextInput creates the text input used by footer prompts.
+func newTextInput() textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = ""
+	ti.Cursor.SetMode(cursor.CursorStatic) // No blink, avoids routing blink messages
+	return ti
+}
+
+// openInput shows the footer text input for the given kind.
+func (m Model) openInput(kind inputKind, prompt, placeholder, value string) Model {
+	m.input = newTextInput()
+	m.input.Placeholder = placeholder
+	m.input.SetValue(value)
+	m.input.Focus()
+	m.inputKind = kind
+	m.inputPrompt = prompt
+	m.statusMessage = ""
+	return m
+}
+
+// closeInput hides the footer text input.
+func (m Model) closeInput() Model {
+	m.input.Blur()
+	m.inputKind = inputNone
+	m.inputPrompt = ""
+	return m
+}
+
+// handleInputPrompt handles key presses while the footer text input is shown.
+func (m Model) handleInputPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		kind := m.inputKind
+		m = m.closeInput()
+		if kind == inputFilter {
+			m = m.clearFilter()
+		}
+		m.updateViewportContent()
+		return m, nil
+	case "enter":
+		kind := m.inputKind
+		value := strings.TrimSpace(m.input.Value())
+		m = m.closeInput()
+		return m.submitInput(kind, value)
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	switch m.inputKind {
+	case inputValue:
+		m.updateViewportContent() // The input is drawn in the list
+	case inputFilter:
+		m = m.applyFilter(m.input.Value())
+	}
+	return m, cmd
+}
+
+// submitInput runs the action associated with a confirmed text input.
+func (m Model) submitInput(kind inputKind, value string) (tea.Model, tea.Cmd) {
+	switch kind {
+	case inputDuplicateSuffix:
+		if m.refuseReadOnly() {
+			return m, nil
+		}
+		if value == "" {
+			m.statusMessage = "Error: environment name cannot be empty."
+			return m, nil
+		}
+		m.statusMessage = "Duplicating..."
+		return m, m.duplicateCmd(value)
+
+	case inputSaveAsPath:
+		if m.refuseReadOnly() {
+			return m, nil
+		}
+		if value == "" {
+			m.statusMessage = "Error: path cannot be empty."
+			return m, nil
+		}
+		m.statusMessage = "Saving..."
+		return m, m.saveAsCmd(value)
+
+	case inputExportPath:
+		if value == "" {
+			m.statusMessage = "Error: path cannot be empty."
+			return m, nil
+		}
+		if m.isLoadedFile(value) {
+			m.statusMessage = "Error: exporting would overwrite a file open in sidem."
+			return m, nil
+		}
+		m.statusMessage = "Exporting..."
+		return m, m.exportCmd(value)
+
+	case inputFilter:
+		return m, nil // Already applied while typing
+
+	case inputGotoLine:
+		lineNumber, err := strconv.Atoi(value)
+		if err != nil || lineNumber < 1 {
+			m.statusMessage = fmt.Sprintf("Error: invalid line number %q.", value)
+			return m, nil
+		}
+		index := itemIndexForLine(m.getCurrentListItems(), m.parsedData, lineNumber)
+		if index == -1 {
+			m.statusMessage = fmt.Sprintf("No variable at or after line %d.", lineNumber)
+			return m, nil
+		}
+		m.cursor = index
+		m.ensureCursorVisible()
+		return m, nil
+
+	case inputOccurrenceValue:
+		return m.setOccurrenceValue(value)
+
+	case inputValue:
+		var cmd tea.Cmd
+		if line := m.editLine; line != nil && line.Value != value {
+			m.pushUndo(m.snapshot(line.Key))
+			line.SetValue(value)
+			m.statusMessage = fmt.Sprintf("Updated %s.", line.Key)
+			cmd = m.markModified()
+		}
+		m.editLine = nil
+		m.updateViewportContent() // Draw the value again in place of the input
+		return m, cmd
+
+	case inputNewKey:
+		if !parser.IsValidKey(value) {
+			m.statusMessage = fmt.Sprintf("Error: invalid key %q (letters, digits and '_', not starting with a digit).", value)
+			return m, nil
+		}
+		m.newKey = value
+		return m.openInput(inputNewValue, value+" =", "value", ""), nil
+
+	case inputNewValue:
+		entry := m.snapshotLayout(m.newKey)
+		line, err := m.parsedData.AddVariable(m.newKey, value)
+		if err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", err)
+			return m, nil
+		}
+		m.pushUndo(entry)
+		m.statusMessage = fmt.Sprintf("Added %s.", m.newKey)
+		m.newKey = ""
+		m.updateViewportContent()
+		if index := itemIndexOfLine(m.getCurrentListItems(), m.parsedData, line); index != -1 {
+			m.cursor = index
+			m.ensureCursorVisible()
+		}
+		return m, m.markModified()
+
+	case inputSnippetName:
+		template, ok := m.options.Snippets[value]
+		if !ok {
+			m.statusMessage = fmt.Sprintf("Error: unknown snippet %q.", value)
+			return m, nil
+		}
+		// The line was chosen when the prompt opened
+		m.snippet.template = template
+		m.snippet.pending = snippet.Placeholders(template)
+		m.snippet.values = make(map[string]string)
+		return m.nextSnippetPlaceholder()
+
+	case inputSnippetPlaceholder:
+		name := m.snippet.pending[0]
+		m.snippet.values[name] = value
+		m.snippet.pending = m.snippet.pending[1:]
+		return m.nextSnippetPlaceholder()
+	}
+	return m, nil
+}
+
+// snippetState tracks a snippet insertion while its placeholders are prompted for.
+type snippetState struct {
+	line     *parser.Line      // Value line receiving the expanded snippet
+	template string            // Template of the chosen snippet
+	pending  []string          // Placeholders still to prompt for
+	values   map[string]string // Placeholder values entered so far
+}
+
+// openValueEditor starts editing the focused value line in place.
+func (m Model) openValueEditor() Model {
+	if m.refuseReadOnly() {
+		return m
+	}
+	line := m.focusedLine()
+	if line == nil {
+		m.statusMessage = "Focus a value line to edit it."
+		return m
+	}
+	if strings.Contains(line.Value, "\n") {
+		m.statusMessage = fmt.Sprintf("The value of %s spans several lines, edit it in the file.", line.Key)
+		return m
+	}
+	m.editLine = line
+	m = m.openInput(inputValue, fmt.Sprintf("Editing %s: Enter to apply, Esc to cancel", line.Key), "", line.Value)
+	m.input.CursorEnd()
+	m.updateViewportContent()
+	return m
+}
+
+// openNewVariablePrompt starts adding a variable, prompting for its key then
+// its value. The focused variable's key is suggested, to add an alternative.
+func (m Model) openNewVariablePrompt() Model {
+	if m.refuseReadOnly() {
+		return m
+	}
+	return m.openInput(inputNewKey, "New variable key:", "KEY", m.focusedGroupKey())
+}
+
+// openSnippetPrompt starts inserting a snippet into the focused value line.
+func (m Model) openSnippetPrompt() Model {
+	if m.refuseReadOnly() {
+		return m
+	}
+	line := m.focusedLine()
+	if line == nil {
+		m.statusMessage = "Focus a value line to insert a snippet."
+		return m
+	}
+	if len(m.options.Snippets) == 0 {
+		m.statusMessage = "No snippets defined in the config file."
+		return m
+	}
+
+	names := make([]string, 0, len(m.options.Snippets))
+	for name := range m.options.Snippets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	m.snippet = snippetState{line: line}
+	return m.openInput(inputSnippetName, fmt.Sprintf("Snippet (%s):", strings.Join(names, ", ")), names[0], "")
+}
+
+// nextSnippetPlaceholder prompts for the next placeholder, or inserts the
+// expanded snippet once all of them have been entered.
+func (m Model) nextSnippetPlaceholder() (tea.Model, tea.Cmd) {
+	if len(m.snippet.pending) > 0 {
+		name := m.snippet.pending[0]
+		return m.openInput(inputSnippetPlaceholder, name+":", "leave empty to keep ${"+name+"}", ""), nil
+	}
+
+	value := snippet.Expand(m.snippet.template, m.snippet.values)
+	m.pushUndo(m.snapshot(m.snippet.line.Key))
+	m.snippet.line.SetValue(value)
+	m.snippet = snippetState{}
+	m.statusMessage = "Snippet inserted."
+	return m, m.markModified()
+}
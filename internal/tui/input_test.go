@@ -0,0 +1,22 @@
+package tui
+
+import "testing"
+
+func TestSnippetInsertion(t *testing.T) {
+	opts := Options{Snippets: map[string]string{"pgurl": "postgres://${USER}@${HOST}/${DB}"}}
+	m := newTestModel(t, "DATABASE_URL=old\n", opts)
+
+	m = press(m, "down", "i", "pgurl", "enter", "app", "enter", "", "enter", "main", "enter")
+
+	if got, want := activeValue(t, m, "DATABASE_URL"), "postgres://app@${HOST}/main"; got != want {
+		t.Errorf("value = %q, want %q", got, want)
+	}
+	if !m.modified {
+		t.Error("inserting a snippet should mark the buffer modified")
+	}
+
+	m = press(m, "u")
+	if got := activeValue(t, m, "DATABASE_URL"); got != "old" {
+		t.Errorf("value after undo = %q, want %q", got, "old")
+	}
+}
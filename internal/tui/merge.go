@@ -0,0 +1,243 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/taha-yassine/sidem/internal/export"
+	"github.com/taha-yassine/sidem/internal/parser"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// mergeLoadedMsg is sent once the changed file has been parsed for a merge.
+type mergeLoadedMsg struct {
+	parsedData *parser.ParsedData
+}
+
+// conflict is a variable whose active value differs between disk and buffer.
+type conflict struct {
+	key        string
+	disk       string // Active value on disk
+	buffer     string // Active value in the buffer
+	onDisk     bool   // False if the variable is inactive or absent on disk
+	inBuffer   bool   // False if the variable is inactive or absent in the buffer
+	keepBuffer bool   // True to keep the buffer's value, false to take the disk's
+}
+
+// computeConflicts returns the variables whose active value differs between
+// the buffer and disk, buffer variables first, each defaulting to the buffer's value.
+func computeConflicts(buffer, disk *parser.ParsedData) []conflict {
+	diskValues := make(map[string]string)
+	for _, v := range export.Active(disk) {
+		diskValues[v.Key] = v.Value
+	}
+
+	var conflicts []conflict
+	seen := make(map[string]bool)
+	for _, v := range export.Active(buffer) {
+		seen[v.Key] = true
+		diskValue, onDisk := diskValues[v.Key]
+		if onDisk && diskValue == v.Value {
+			continue
+		}
+		conflicts = append(conflicts, conflict{key: v.Key, disk: diskValue, buffer: v.Value, onDisk: onDisk, inBuffer: true, keepBuffer: true})
+	}
+	for _, v := range export.Active(disk) {
+		if !seen[v.Key] {
+			conflicts = append(conflicts, conflict{key: v.Key, disk: v.Value, onDisk: true, keepBuffer: true})
+		}
+	}
+	return conflicts
+}
+
+// applyMerge applies the buffer's side of the chosen conflicts to the disk content.
+func applyMerge(disk *parser.ParsedData, conflicts []conflict) error {
+	for _, c := range conflicts {
+		if !c.keepBuffer {
+			continue
+		}
+		group, ok := disk.VariableGroups[c.key]
+		if !c.inBuffer {
+			if ok {
+				group.IsSelected = false
+			}
+			continue
+		}
+		if !ok {
+			if _, err := disk.AddVariable(c.key, c.buffer); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Prefer selecting an existing occurrence holding the buffer's value
+		index := -1
+		for i, line := range group.Lines {
+			if line.Value == c.buffer {
+				index = i
+				break
+			}
+		}
+		if index == -1 {
+			index = max(group.SelectedLineIdx, 0)
+			group.Lines[index].SetValue(c.buffer)
+		}
+		group.IsSelected = true
+		group.SelectedLineIdx = index
+	}
+	return nil
+}
+
+// loadMergeCmd creates a command parsing the changed file for a merge.
+func (m Model) loadMergeCmd() tea.Cmd {
+	return func() tea.Msg {
+		pd, err := parser.ParseFileWithOptions(m.filePath, parser.Options{CommentMarker: m.options.CommentMarker})
+		if err != nil {
+			return errMsg{fmt.Errorf("failed to reload file: %w", err)}
+		}
+		return mergeLoadedMsg{parsedData: pd}
+	}
+}
+
+// openMerge shows the conflict view between the buffer and the reloaded content.
+func (m Model) openMerge(disk *parser.ParsedData) Model {
+	m.statusMessage = ""
+	m.mergeDisk = disk
+	m.conflicts = computeConflicts(m.parsedData, disk)
+	m.mergeCursor = 0
+	m.showMerge = true
+	return m
+}
+
+// handleMerge handles key presses while the conflict view is shown.
+func (m Model) handleMerge(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.showMerge = false
+		m.mergeDisk = nil
+		m.conflicts = nil
+		m.statusMessage = "Kept local changes. File change ignored."
+		if m.watcher != nil {
+			return m, m.watcher.WatchFileCmd()
+		}
+		return m, nil
+	case "up", "k":
+		if m.mergeCursor > 0 {
+			m.mergeCursor--
+		}
+	case "down", "j":
+		if m.mergeCursor < len(m.conflicts)-1 {
+			m.mergeCursor++
+		}
+	case "left", "h":
+		m.chooseConflict(false)
+	case "right", "l":
+		m.chooseConflict(true)
+	case " ":
+		if m.mergeCursor < len(m.conflicts) {
+			m.chooseConflict(!m.conflicts[m.mergeCursor].keepBuffer)
+		}
+	case "enter":
+		return m.confirmMerge()
+	}
+	return m, nil
+}
+
+// chooseConflict records whether the highlighted conflict keeps the buffer's value.
+func (m *Model) chooseConflict(keepBuffer bool) {
+	if m.mergeCursor < 0 || m.mergeCursor >= len(m.conflicts) {
+		return
+	}
+	// Copy on write, the slice is shared with previous models
+	conflicts := append([]conflict(nil), m.conflicts...)
+	conflicts[m.mergeCursor].keepBuffer = keepBuffer
+	m.conflicts = conflicts
+}
+
+// confirmMerge replaces the buffer with the disk content, keeping the buffer's
+// values for the conflicts resolved that way.
+func (m Model) confirmMerge() (tea.Model, tea.Cmd) {
+	m.savedActive = activeValues(m.mergeDisk)
+	if err := applyMerge(m.mergeDisk, m.conflicts); err != nil {
+		m.statusMessage = fmt.Sprintf("Error: %v", err)
+		return m, nil
+	}
+
+	kept := 0
+	for _, c := range m.conflicts {
+		if c.keepBuffer {
+			kept++
+		}
+	}
+	m.parsedData = m.mergeDisk
+	m.forgetHistory()
+	m.showMerge = false
+	m.mergeDisk = nil
+	m.conflicts = nil
+	m.modified = false
+	m.cursor = 0
+	m.focusIndex = 0
+	m.resizeViewport() // The header grows with invalid lines
+	m.updateViewportContent()
+	m.ensureCursorVisible()
+
+	cmds := []tea.Cmd{m.setStatus(fmt.Sprintf("Merged changes from disk, kept %d local value(s).", kept))}
+	if kept > 0 {
+		cmds = append(cmds, m.markModified())
+	}
+	if m.watcher != nil {
+		cmds = append(cmds, m.watcher.WatchFileCmd())
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// renderMerge renders the conflict view in place of the list, height rows tall.
+func (m *Model) renderMerge(height int) string {
+	var builder strings.Builder
+	builder.WriteString(m.styles.FileHeader.Render("Variables changed on disk (left: disk, right: buffer)"))
+	if len(m.conflicts) == 0 {
+		builder.WriteString("\n" + m.styles.NormalLine.Render("  No differences, press Enter to reload."))
+	}
+
+	keyWidth := 0
+	for _, c := range m.conflicts {
+		keyWidth = max(keyWidth, len(c.key))
+	}
+
+	start := max(0, m.mergeCursor-(height-2))
+	for i := start; i < len(m.conflicts) && i-start < height-1; i++ {
+		c := m.conflicts[i]
+		pointer := "  "
+		if i == m.mergeCursor {
+			pointer = iconPointer
+		}
+		disk := m.mergeValue(c.key, c.disk, c.onDisk)
+		buffer := m.mergeValue(c.key, c.buffer, c.inBuffer)
+		if c.keepBuffer {
+			disk = m.styles.DisabledLine.Render(disk)
+			buffer = m.styles.FocusedLine.Render(buffer)
+		} else {
+			disk = m.styles.FocusedLine.Render(disk)
+			buffer = m.styles.DisabledLine.Render(buffer)
+		}
+		key := m.styles.KeyStyle.Render(c.key + strings.Repeat(" ", keyWidth-len(c.key)))
+		builder.WriteString(fmt.Sprintf("\n%s%s  %s  ⇄  %s", pointer, key, disk, buffer))
+	}
+
+	return lipgloss.NewStyle().Width(m.width).Height(height).Render(builder.String())
+}
+
+// mergeValue formats one side of a conflict, masking it like the list does.
+func (m *Model) mergeValue(key, value string, active bool) string {
+	switch {
+	case !active:
+		return "(unset)"
+	case value == "":
+		return iconEmptyValue
+	case m.options.MaskSecrets && !m.peeking() && isSecretKey(key, m.options.SecretKeys):
+		return iconMasked
+	}
+	return value
+}
@@ -0,0 +1,42 @@
+package tui
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestComputeConflicts(t *testing.T) {
+	buffer := parseContent(t, "SAME=1\nCHANGED=2\n# OFF_HERE=3\nNEW_HERE=4\n# A=x\nA=y\n")
+	disk := parseContent(t, "SAME=1\nCHANGED=20\nOFF_HERE=3\nNEW_THERE=5\nA=x\n# A=y\n")
+
+	got := computeConflicts(buffer, disk)
+	want := []conflict{
+		{key: "CHANGED", disk: "20", buffer: "2", onDisk: true, inBuffer: true, keepBuffer: true},
+		{key: "NEW_HERE", buffer: "4", inBuffer: true, keepBuffer: true},
+		{key: "A", disk: "x", buffer: "y", onDisk: true, inBuffer: true, keepBuffer: true},
+		{key: "OFF_HERE", disk: "3", onDisk: true, keepBuffer: true},
+		{key: "NEW_THERE", disk: "5", onDisk: true, keepBuffer: true},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("conflicts are %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("conflict %d is %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	if got := computeConflicts(buffer, buffer); len(got) != 0 {
+		t.Errorf("a buffer conflicts with itself: %+v", got)
+	}
+
+	// Taking the disk's side for CHANGED and the buffer's for the rest
+	got[0].keepBuffer = false
+	if err := applyMerge(disk, got); err != nil {
+		t.Fatal(err)
+	}
+	wantValues := map[string]string{"SAME": "1", "CHANGED": "20", "NEW_HERE": "4", "A": "y"}
+	if values := activeValues(disk); !maps.Equal(values, wantValues) {
+		t.Errorf("merged values are %v, want %v", values, wantValues)
+	}
+}
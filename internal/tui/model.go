@@ -2,10 +2,17 @@ package tui
 
 import (
 	"context"
+	"crypto/sha256"
+	"os"
+	"strings"
+	"time"
 
+	"github.com/taha-yassine/sidem/internal/clipboard"
+	"github.com/taha-yassine/sidem/internal/config"
 	"github.com/taha-yassine/sidem/internal/parser"
 	"github.com/taha-yassine/sidem/internal/watcher"
 
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -19,12 +26,62 @@ const (
 	iconRadioOn     = "*"
 	iconPointer     = "> "
 	iconEmptyValue  = "<empty>"
+	iconUnsafe      = " ⚠"          // Variable that would not round-trip on save
+	iconKeyCase     = " ≠"          // Key not matching the --key-case policy
+	iconEnvOverride = " ⇐ env: "    // Variable shadowed by the process environment
+	iconMasked      = "••••••••"    // Hidden secret value
+	iconOverride    = " ⇒ "         // Value overridden in memory by --env
+	iconChanged     = " ↻"          // Value changed on disk by the last reload
+	iconResolved    = " ⟲"          // Value shown with its references resolved
+	iconLongValue   = "‼ "          // Value longer than --warn-value-length
+	iconRequired    = " (required)" // Variable annotated as required
+	iconNewline     = "↵"           // Line break of a multiline value
+	iconConflict    = " ⧉"          // Variable uncommented on several lines of the file
+	iconInvalid     = "⚠ "          // Line that is neither a comment nor a valid assignment
 )
 
+// Options holds the user preferences passed in from the command line.
+type Options struct {
+	CopyQuoted         bool              // Copy values with 'y' in their quoted .env form when needed
+	AutoSave           time.Duration     // Save automatically this long after the last change (0 disables)
+	KeyCase            parser.KeyCase    // Key naming policy applied on save
+	Spacing            parser.Spacing    // Whitespace policy around '=' applied on save
+	CommentMarker      string            // Marker of the file's dialect, "#" or ";"
+	ShowEnv            bool              // Flag variables already set in the process environment
+	Compact            bool              // Show single-occurrence groups on one row
+	AlignValues        bool              // Align the values of compact rows in a column
+	MaskSecrets        bool              // Hide the values of variables with sensitive names
+	Overrides          map[string]string // In-memory values from --env, never saved unless promoted
+	Clipboard          clipboard.Backend // How copied text reaches the clipboard
+	StatusTimeout      time.Duration     // How long transient status messages stay (0 keeps them until the next key press)
+	ContextLines       int               // Rows kept visible above and below the cursor when scrolling
+	GroupContext       bool              // Keep a focused group's occurrences and the next header visible when they fit
+	ErrorOnUnsavedQuit bool              // Exit with ExitCodeUnsaved when quitting without saving changes
+	AnnotateChanges    bool              // Write a "# last-changed:" comment above variables changed through the TUI
+	TrimTrailingBlank  bool              // Remove blank lines at the end of the file on save
+	WarnValueLength    int               // Flag values longer than this many characters (0 disables)
+	ReadOnly           bool              // Refuse to write the file, e.g. when it is too large to be edited safely
+	Watch              watcher.Options   // How the file is watched for external changes
+	NoBackup           bool              // Never back up the file before overwriting it
+	BackupDir          string            // Directory of timestamped backups, "" for a .bak next to the file
+	HideSingleRadio    bool              // Hide the radio column of groups with a single occurrence
+	NoFlash            bool              // Don't flash the value made active on toggle
+
+	ConfigPath string          // Configuration file, re-read with Ctrl+L
+	FlagsSet   map[string]bool // Command-line flags given explicitly, which the configuration doesn't override
+	Theme      string          // "nature" for the nature theme, the default one otherwise
+
+	Snippets          map[string]string     // Value templates insertable with 'i', by name
+	ReferenceTemplate string                // What 'R' copies for secrets, {key} being replaced by the variable name
+	HeaderAccents     []config.HeaderAccent // Header tints by file name, config.DefaultHeaderAccents if nil
+	SecretKeys        []string              // Parts of sensitive key names, the built-in list if nil
+}
+
 // Model represents the state of the TUI application.
 type Model struct {
 	parsedData *parser.ParsedData // The parsed .env file data
 	filePath   string             // Path to the .env file being managed
+	options    Options            // User preferences
 
 	cursor     int // Current row index in the logical list (includes group headers and value lines)
 	focusIndex int // Index of the currently focused VariableGroup in parsedData.GroupOrder
@@ -34,20 +91,82 @@ type Model struct {
 	width    int
 	height   int
 
-	styles Styles // Styling for different UI elements
+	styles      Styles   // Styling for different UI elements
+	natureTheme bool     // True when the nature styles are shown instead of the default ones
+	groupByFile bool     // True when the list is split into sections by source file
+	compact     bool     // True when single-occurrence groups are shown on one row
+	alignValues bool     // True when compact rows' values are aligned in a column
+	showTypes   bool     // True when the inferred type of values is shown in a right-hand column
+	showDetail  bool     // True when the focused variable's details are shown next to the list on wide terminals
+	hideRadio   bool     // True when the radio column is hidden for single-occurrence groups
+	sortMode    sortMode // Order the groups are listed in
+
+	pinned     []string        // Keys listed first, from the front matter
+	maskedKeys map[string]bool // Keys whose values are always masked, from the front matter
 
 	// State flags
 	modified          bool // True if there are unsaved changes
 	quitting          bool // True when the user has initiated quit sequence
 	showQuitPrompt    bool // True when showing the "Save before quitting?" prompt
 	quittingAfterSave bool // Set to true when quit is initiated via 'Save & Quit'
-
-	statusMessage string // To display feedback like "Saved", "Error", etc.
+	quitUnsaved       bool // True when the user chose to quit without saving changes
+	showOrphanPrompt  bool // True when asking whether to save despite orphaned lines
+	orphanCount       int  // Number of orphaned lines reported by the last save attempt
+
+	pendingDelete *pendingDelete // Removal waiting for confirmation, nil if none
+	saveOrphans   bool           // Set while creating a save confirmed despite orphaned lines
+	filter        string         // Only groups whose key matches are listed, see matchesFilter
+	undoStack     []undoEntry    // States before the recorded changes, most recent last
+	redoStack     []undoEntry    // States before the undone changes, most recent last
+
+	// Command palette state
+	showPalette   bool            // True when the command palette overlay is shown
+	paletteInput  textinput.Model // Fuzzy search query
+	paletteCursor int             // Index of the highlighted action among the matches
+
+	// Reload merge state
+	showMerge   bool               // True when the per-variable conflict view is shown
+	mergeDisk   *parser.ParsedData // Content reloaded from disk, merged into on apply
+	conflicts   []conflict         // Variables whose active value differs between disk and buffer
+	mergeCursor int                // Index of the highlighted conflict
+
+	showSharedValues bool // True when values shared by several variables are listed instead of the variables
+
+	occurrencesKey   string // Key of the group whose occurrences table is shown, "" when hidden
+	occurrenceCursor int    // Index of the highlighted occurrence in the table
+
+	bookmarks       map[rune]string // Group keys bookmarked with 'm', by letter
+	pendingBookmark bookmarkMode    // Bookmark key waiting for its letter
+
+	// Footer text input state
+	input       textinput.Model // Text input shown in the footer by prompts
+	inputKind   inputKind       // What the text input is collecting (inputNone when hidden)
+	inputPrompt string          // Label displayed before the text input
+	snippet     snippetState    // Snippet being inserted
+	editLine    *parser.Line    // Value line edited in place with 'e'
+	newKey      string          // Key of the variable being added with 'a', while its value is prompted for
+
+	statusMessage string            // To display feedback like "Saved", "Error", etc.
+	unsafeKeys    map[string]bool   // Keys flagged by the pre-save verification
+	savedActive   map[string]string // Active values when last loaded or saved, to detect changes to annotate
+	envOverrides  map[string]string // Live values of variables set in the process environment
+	overrides     map[string]string // Launch overrides (--env) not yet promoted, by key
+
+	// Auto-save state
+	autosaveGen int // Incremented on every change, used to debounce auto-saves
+	revision    int // Incremented on every change to the buffer, to tell whether a save still matches it
+
+	peekUntil     time.Time                  // Masked values are revealed until this time
+	changedOnDisk map[string]time.Time       // Keys changed by the last reload, highlighted until their expiry
+	resolvedLines map[*parser.Line]bool      // Lines whose value is shown with references resolved
+	flashed       map[*parser.Line]time.Time // Lines just made active, flashed until their expiry
 
 	// Hot Reload state
 	watcher             *watcher.Watcher
 	watcherCtx          context.Context    // Context for managing watcher lifecycle
 	watcherCancel       context.CancelFunc // Function to cancel the context
+	writtenHash         [sha256.Size]byte  // Hash of the content last saved, to ignore our own writes
+	hasWrittenHash      bool               // True once writtenHash holds a saved content hash
 	showReloadPrompt    bool               // True when showing "File changed externally..." prompt
 	pendingReloadAction func() tea.Msg     // Action to take after reload prompt (reload or keep)
 }
@@ -60,6 +179,11 @@ type Styles struct {
 	EmptyValueStyle lipgloss.Style // Style for <empty> placeholder
 	SelectedIcon    lipgloss.Style
 	KeyStyle        lipgloss.Style // Style for variable keys
+	FileHeader      lipgloss.Style // Style for source file section headers
+	ScrollIndicator lipgloss.Style // Style for the footer scroll position
+	OverrideStyle   lipgloss.Style // Style for in-memory overrides
+	ChangedLine     lipgloss.Style // Highlight for variables changed on disk by a reload
+	FlashLine       lipgloss.Style // Brief highlight of a value just made active
 	HeaderTitle     lipgloss.Style
 	HeaderFileInfo  lipgloss.Style
 	Header          lipgloss.Style
@@ -119,6 +243,13 @@ func DefaultStyles() Styles {
 		PromptStyle:    lipgloss.NewStyle().Foreground(draculaPink).Bold(true),   // Pink for prompts
 
 		KeyStyle: base.Bold(true), // Keep Key style bold with base foreground
+
+		FileHeader: lipgloss.NewStyle().Foreground(draculaPurple).Underline(true), // Purple for file sections
+
+		ScrollIndicator: lipgloss.NewStyle().Foreground(draculaPurple),              // Purple for scroll position
+		OverrideStyle:   lipgloss.NewStyle().Foreground(draculaOrange).Italic(true), // Orange italic for overrides
+		ChangedLine:     lipgloss.NewStyle().Foreground(draculaYellow).Bold(true),   // Yellow for changes on disk
+		FlashLine:       lipgloss.NewStyle().Foreground(draculaGreen).Bold(true),    // Green for a value just made active
 	}
 }
 
@@ -167,20 +298,37 @@ func NatureStyles() Styles {
 		PromptStyle:    lipgloss.NewStyle().Foreground(darkSeaGreen).Bold(true),
 
 		KeyStyle: base.Bold(true),
+
+		FileHeader: lipgloss.NewStyle().Foreground(sage).Underline(true),
+
+		ScrollIndicator: lipgloss.NewStyle().Foreground(jungleGreen),
+		OverrideStyle:   lipgloss.NewStyle().Foreground(ochre).Italic(true),
+		ChangedLine:     lipgloss.NewStyle().Foreground(ochre).Bold(true),
+		FlashLine:       lipgloss.NewStyle().Foreground(jungleGreen).Bold(true),
 	}
 }
 
 // InitialModel creates the initial model for the Bubble Tea program.
-func InitialModel(filePath string, pd *parser.ParsedData, w *watcher.Watcher) Model {
+func InitialModel(filePath string, pd *parser.ParsedData, w *watcher.Watcher, opts Options) Model {
 	// Create a cancellable context for the watcher
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return Model{
+	var envOverrides map[string]string
+	if opts.ShowEnv {
+		envOverrides = detectEnvOverrides(pd, os.Environ())
+	}
+
+	m := Model{
+		natureTheme:       opts.Theme == "nature",
 		parsedData:        pd,
 		filePath:          filePath,
+		options:           opts,
+		compact:           opts.Compact,
+		hideRadio:         opts.HideSingleRadio,
+		alignValues:       opts.AlignValues,
 		cursor:            0,
 		focusIndex:        0,
-		styles:            DefaultStyles(),
+		styles:            themeStyles(opts.Theme == "nature"),
 		modified:          false,
 		quitting:          false,
 		showQuitPrompt:    false,
@@ -190,15 +338,91 @@ func InitialModel(filePath string, pd *parser.ParsedData, w *watcher.Watcher) Mo
 		watcherCtx:        ctx,
 		watcherCancel:     cancel,
 		showReloadPrompt:  false,
+		envOverrides:      envOverrides,
+		overrides:         opts.Overrides,
+		savedActive:       activeValues(pd),
 		// Viewport initialized in first Update with WindowSizeMsg
 	}
+	return m.applyFrontMatter()
+}
+
+// WithConfig returns the options updated with the preferences of cfg. Options
+// whose command-line flag was given explicitly keep their value.
+func (o Options) WithConfig(cfg *config.Config) Options {
+	o.Snippets = cfg.Snippets
+	o.ReferenceTemplate = cfg.ReferenceTemplate
+	o.HeaderAccents = cfg.HeaderAccents
+	o.SecretKeys = cfg.SecretKeys
+	if cfg.Theme != "" {
+		o.Theme = cfg.Theme
+	}
+	if cfg.StatusTimeout != nil && !o.FlagsSet["status-timeout"] {
+		o.StatusTimeout = time.Duration(*cfg.StatusTimeout)
+	}
+	if cfg.MaskSecrets != nil && !o.FlagsSet["mask-secrets"] {
+		o.MaskSecrets = *cfg.MaskSecrets
+	}
+	return o
+}
+
+// themeStyles returns the styles of the nature theme if nature is set, the default ones otherwise.
+func themeStyles(nature bool) Styles {
+	if nature {
+		return NatureStyles()
+	}
+	return DefaultStyles()
+}
+
+// applyFrontMatter applies the settings of the file's front matter block:
+// theme (default or nature), mask_secrets, mask (keys always masked) and
+// pinned (keys listed first).
+func (m Model) applyFrontMatter() Model {
+	fm := m.parsedData.FrontMatter()
+	if fm == nil {
+		return m
+	}
+	if fm.Settings["theme"] == "nature" {
+		m.natureTheme = true
+		m.styles = NatureStyles()
+	}
+	if fm.Bool("mask_secrets") {
+		m.options.MaskSecrets = true
+	}
+	if keys := fm.List("mask"); len(keys) > 0 {
+		m.maskedKeys = make(map[string]bool, len(keys))
+		for _, key := range keys {
+			m.maskedKeys[key] = true
+		}
+	}
+	m.pinned = fm.List("pinned")
+	return m
+}
+
+// detectEnvOverrides returns the variables of pd that are already set in environ
+// (as returned by os.Environ), mapped to their live value. Such variables are
+// shadowed by the process environment when the .env file is loaded.
+func detectEnvOverrides(pd *parser.ParsedData, environ []string) map[string]string {
+	overrides := make(map[string]string)
+	if pd == nil {
+		return overrides
+	}
+	for _, kv := range environ {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			continue
+		}
+		if _, exists := pd.VariableGroups[key]; exists {
+			overrides[key] = value
+		}
+	}
+	return overrides
 }
 
 // Init is the first command ran by the Bubble Tea program.
 func (m Model) Init() tea.Cmd {
 	if m.watcher != nil {
 		// Start the watcher in a goroutine
-		m.watcher.Start(m.watcherCtx, m.filePath)
+		m.watcher.Start(m.watcherCtx, m.watchedPaths()...)
 		// Return the command to listen for watcher events
 		return m.watcher.WatchFileCmd()
 	}
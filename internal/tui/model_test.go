@@ -0,0 +1,68 @@
+package tui
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetectEnvOverrides(t *testing.T) {
+	m := newTestModel(t, "HOST=db\n# PORT=5432\nDEBUG=false\n", Options{})
+	environ := []string{"PATH=/usr/bin", "HOST=localhost", "PORT=", "debug=true", "MALFORMED"}
+	want := map[string]string{"HOST": "localhost", "PORT": ""} // Case sensitive, commented-out keys included
+	if got := detectEnvOverrides(m.parsedData, environ); !maps.Equal(got, want) {
+		t.Errorf("overrides = %v, want %v", got, want)
+	}
+}
+
+func TestShowEnvFlagsShadowedKeys(t *testing.T) {
+	t.Setenv("SIDEM_TEST_HOST", "live")
+	m := newTestModel(t, "SIDEM_TEST_HOST=db\nSIDEM_TEST_UNSET=1\n", Options{ShowEnv: true})
+	if want := map[string]string{"SIDEM_TEST_HOST": "live"}; !maps.Equal(m.envOverrides, want) {
+		t.Errorf("overrides = %v, want %v", m.envOverrides, want)
+	}
+	if without := newTestModel(t, "SIDEM_TEST_HOST=db\n", Options{}); len(without.envOverrides) != 0 {
+		t.Errorf("overrides detected without ShowEnv: %v", without.envOverrides)
+	}
+}
+
+func TestReloadPreferences(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	writeConfig := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConfig(`{}`)
+	m := newTestModel(t, "API_TOKEN=hunter2\n", Options{
+		ConfigPath:    configPath,
+		MaskSecrets:   true,
+		StatusTimeout: time.Second,
+		FlagsSet:      map[string]bool{"mask-secrets": true},
+	})
+
+	writeConfig(`{"theme": "nature", "mask_secrets": false, "status_timeout": "5s", "snippets": {"port": "5432"}}`)
+	m = press(m, "ctrl+l")
+	nature := NatureStyles()
+	if !m.natureTheme || m.styles.KeyStyle.GetForeground() != nature.KeyStyle.GetForeground() {
+		t.Error("the nature theme wasn't applied")
+	}
+	if m.options.StatusTimeout != 5*time.Second || m.options.Snippets["port"] != "5432" {
+		t.Errorf("status timeout %v, snippets %v", m.options.StatusTimeout, m.options.Snippets)
+	}
+	if !m.options.MaskSecrets {
+		t.Error("the configuration overrode --mask-secrets given on the command line")
+	}
+
+	// A broken file leaves the preferences as they were
+	writeConfig(`{"theme": `)
+	before := m.options
+	m = press(m, "ctrl+l")
+	if !strings.HasPrefix(m.statusMessage, "Error: error parsing config") || !m.natureTheme || m.options.StatusTimeout != before.StatusTimeout {
+		t.Errorf("status %q, nature theme %v, status timeout %v after a failed reload", m.statusMessage, m.natureTheme, m.options.StatusTimeout)
+	}
+}
@@ -0,0 +1,185 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/taha-yassine/sidem/internal/parser"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// occurrenceRow is a row of the occurrences table of a group.
+type occurrenceRow struct {
+	value     string
+	comment   string
+	commented bool // Occurrence commented out in the file
+	active    bool // Occurrence selected as the group's value
+	line      int  // 1-based line number in its source file
+}
+
+// occurrenceRows builds the rows of the occurrences table of group, in file order.
+func occurrenceRows(group *parser.VariableGroup) []occurrenceRow {
+	rows := make([]occurrenceRow, len(group.Lines))
+	for i, line := range group.Lines {
+		rows[i] = occurrenceRow{
+			value:     line.Value,
+			comment:   line.Comment,
+			commented: line.IsCommentedOut,
+			active:    group.IsSelected && group.SelectedLineIdx == i,
+			line:      line.LineNumber,
+		}
+	}
+	return rows
+}
+
+// openOccurrences shows the occurrences table of the focused group.
+func (m Model) openOccurrences() Model {
+	key := m.focusedGroupKey()
+	if key == "" {
+		m.statusMessage = "Focus a variable to list its occurrences."
+		return m
+	}
+	m.occurrencesKey = key
+	m.occurrenceCursor = 0
+	return m
+}
+
+// occurrencesGroup returns the group shown in the occurrences table, or nil if none is.
+func (m *Model) occurrencesGroup() *parser.VariableGroup {
+	if m.occurrencesKey == "" {
+		return nil
+	}
+	return m.parsedData.VariableGroups[m.occurrencesKey]
+}
+
+// handleOccurrences handles key presses while the occurrences table is shown.
+func (m Model) handleOccurrences(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	group := m.occurrencesGroup()
+	if group == nil {
+		m.occurrencesKey = ""
+		return m, nil
+	}
+
+	switch msg.String() {
+	case " ", "enter", "e", "d": // Changes to the occurrences
+		if m.refuseReadOnly() {
+			return m, nil
+		}
+	}
+
+	switch msg.String() {
+	case "esc", "q", "o":
+		m.occurrencesKey = ""
+		m.updateViewportContent()
+	case "up", "k":
+		if m.occurrenceCursor > 0 {
+			m.occurrenceCursor--
+		}
+	case "down", "j":
+		if m.occurrenceCursor < len(group.Lines)-1 {
+			m.occurrenceCursor++
+		}
+	case " ", "enter": // Make the highlighted occurrence the active one
+		if group.IsSelected && group.SelectedLineIdx == m.occurrenceCursor {
+			return m, nil
+		}
+		m.pushUndo(m.snapshot(group.Key))
+		group.IsSelected = true
+		group.SelectedLineIdx = m.occurrenceCursor
+		return m, m.markModified()
+	case "e": // Edit the highlighted occurrence's value
+		line := group.Lines[m.occurrenceCursor]
+		if strings.Contains(line.Value, "\n") {
+			m.statusMessage = fmt.Sprintf("This value of %s spans several lines, edit it in the file.", group.Key)
+			return m, nil
+		}
+		return m.openInput(inputOccurrenceValue, fmt.Sprintf("%s (line %d):", group.Key, line.LineNumber), "", line.Value), nil
+	case "d": // Delete the highlighted occurrence
+		line := group.Lines[m.occurrenceCursor]
+		entry := m.snapshotLayout(group.Key)
+		if err := m.parsedData.RemoveOccurrence(group.Key, m.occurrenceCursor); err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %v", err)
+			return m, nil
+		}
+		if m.occurrencesGroup() == nil {
+			m.occurrencesKey = ""
+		} else {
+			m.occurrenceCursor = min(m.occurrenceCursor, len(group.Lines)-1)
+		}
+		m.clampCursor()
+		m.pushUndo(entry)
+		m.statusMessage = fmt.Sprintf("Deleted line %d of %s.", line.LineNumber, group.Key)
+		return m, m.markModified()
+	}
+	return m, nil
+}
+
+// setOccurrenceValue sets the value of the occurrence highlighted in the occurrences table.
+func (m Model) setOccurrenceValue(value string) (tea.Model, tea.Cmd) {
+	group := m.occurrencesGroup()
+	if group == nil || m.occurrenceCursor >= len(group.Lines) {
+		return m, nil
+	}
+	line := group.Lines[m.occurrenceCursor]
+	if line.Value == value {
+		return m, nil
+	}
+	m.pushUndo(m.snapshot(group.Key))
+	line.SetValue(value)
+	return m, m.markModified()
+}
+
+// renderOccurrences renders the occurrences table of a group in place of the list,
+// height rows tall.
+func (m *Model) renderOccurrences(height int) string {
+	group := m.occurrencesGroup()
+	if group == nil {
+		return ""
+	}
+	rows := occurrenceRows(group)
+
+	var builder strings.Builder
+	builder.WriteString(m.styles.FileHeader.Render(fmt.Sprintf("Occurrences of %s", group.Key)))
+	builder.WriteString("\n" + m.styles.DisabledLine.Render(fmt.Sprintf("    %-6s %-9s %s", "Line", "Commented", "Value  # Comment")))
+
+	start := max(0, m.occurrenceCursor-(height-3))
+	for i := start; i < len(rows) && i-start < height-2; i++ {
+		row := rows[i]
+		pointer := "  "
+		style := m.styles.NormalLine
+		if i == m.occurrenceCursor {
+			pointer = iconPointer
+			style = m.styles.FocusedLine
+		} else if !row.active {
+			style = m.styles.DisabledLine
+		}
+
+		marker := iconRadioOff
+		if row.active {
+			marker = iconRadioOn
+		}
+		state := "no"
+		if row.commented {
+			state = "yes"
+		}
+		value := row.value
+		switch {
+		case value == "":
+			value = iconEmptyValue
+		case m.isMasked(ListItem{groupIndex: slices.Index(m.parsedData.GroupOrder, group.Key), value: value}):
+			value = iconMasked
+		}
+
+		text := fmt.Sprintf("%s%s L%-5d %-9s %s", pointer, marker, row.line, state, value)
+		if row.comment != "" {
+			text += "  # " + row.comment
+		}
+		builder.WriteString("\n" + style.Render(ansi.Truncate(text, m.width, "…")))
+	}
+
+	return lipgloss.NewStyle().Width(m.width).Height(height).MaxHeight(height).Render(builder.String())
+}
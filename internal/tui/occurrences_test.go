@@ -0,0 +1,54 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/taha-yassine/sidem/internal/parser"
+)
+
+func TestOccurrenceRows(t *testing.T) {
+	data, err := parser.Parse(strings.NewReader("# API=http://dev # dev\nOTHER=1\nAPI=https://prod # prod\n# API=\n"), ".env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	group := data.VariableGroups["API"]
+
+	want := []occurrenceRow{
+		{value: "http://dev", comment: "dev", commented: true, line: 1},
+		{value: "https://prod", comment: "prod", active: true, line: 3},
+		{value: "", commented: true, line: 4},
+	}
+	if got := occurrenceRows(group); !slices.Equal(got, want) {
+		t.Errorf("rows are %+v, want %+v", got, want)
+	}
+
+	// A disabled group has no active occurrence
+	group.IsSelected = false
+	want[1].active = false
+	if got := occurrenceRows(group); !slices.Equal(got, want) {
+		t.Errorf("rows of the disabled group are %+v, want %+v", got, want)
+	}
+}
+
+func TestOccurrencesTable(t *testing.T) {
+	m := newTestModel(t, "API=https://prod # prod\n# API=http://dev # dev\n", Options{})
+	m = press(m, "o", "down", "enter")
+	if got := activeValue(t, m, "API"); got != "http://dev" {
+		t.Errorf("active value is %q after picking the second occurrence, want http://dev", got)
+	}
+
+	m = press(m, "e", "ctrl+u", "http://local", "enter")
+	if got := m.parsedData.VariableGroups["API"].Lines[1].Value; got != "http://local" {
+		t.Errorf("edited occurrence is %q", got)
+	}
+
+	m = press(m, "d", "esc")
+	if m.occurrencesKey != "" {
+		t.Error("table still shown after esc")
+	}
+	if got := len(m.parsedData.VariableGroups["API"].Lines); got != 1 {
+		t.Errorf("%d occurrences left after deleting one, want 1", got)
+	}
+}
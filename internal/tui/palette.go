@@ -0,0 +1,178 @@
+package tui
+
+import (
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// paletteAction is a named action that can be run from the command palette.
+type paletteAction struct {
+	name string
+	run  func(Model) (Model, tea.Cmd)
+}
+
+// paletteActions is the registry of actions listed in the command palette, in display order.
+var paletteActions []paletteAction
+
+// registerAction adds an action to the command palette.
+func registerAction(name string, run func(Model) (Model, tea.Cmd)) {
+	paletteActions = append(paletteActions, paletteAction{name: name, run: run})
+}
+
+func init() {
+	registerAction("Save", Model.save)
+	registerAction("Fast save (no backup)", Model.fastSave)
+	registerAction("Save as…", func(m Model) (Model, tea.Cmd) { return m.openSaveAsPrompt(), nil })
+	registerAction("Undo", Model.undo)
+	registerAction("Redo", Model.redo)
+	registerAction("Filter by key…", func(m Model) (Model, tea.Cmd) { return m.openFilter(), nil })
+	registerAction("Export active variables…", func(m Model) (Model, tea.Cmd) { return m.openExportPrompt(), nil })
+	registerAction("Duplicate to environment…", func(m Model) (Model, tea.Cmd) { return m.openDuplicatePrompt(), nil })
+	registerAction("Toggle selection", Model.toggle)
+	registerAction("Add variable…", func(m Model) (Model, tea.Cmd) { return m.openNewVariablePrompt(), nil })
+	registerAction("Delete focused occurrence or variable…", func(m Model) (Model, tea.Cmd) { return m.openDeletePrompt(), nil })
+	registerAction("Edit focused value", func(m Model) (Model, tea.Cmd) { return m.openValueEditor(), nil })
+	registerAction("Copy focused line", Model.copySelected)
+	registerAction("Resolve focused value", func(m Model) (Model, tea.Cmd) { return m.toggleResolved(), nil })
+	registerAction("Copy active KEY=value", Model.copyPair)
+	registerAction("Copy all occurrences", Model.copyOccurrences)
+	registerAction("Copy secret reference", Model.copyReference)
+	registerAction("Copy file path", Model.copyFilePath)
+	registerAction("Peek at masked values", Model.peek)
+	registerAction("Toggle secret masking", Model.toggleMasking)
+	registerAction("Promote override", Model.promoteOverride)
+	registerAction("Show occurrences table", func(m Model) (Model, tea.Cmd) { return m.openOccurrences(), nil })
+	registerAction("Sort occurrences by comment", Model.sortOccurrences)
+	registerAction("Toggle required", Model.toggleRequired)
+	registerAction("Cycle duplicate active lines", Model.cycleConflict)
+	registerAction("Go to line…", func(m Model) (Model, tea.Cmd) { return m.openGotoLinePrompt(), nil })
+	registerAction("Insert snippet…", func(m Model) (Model, tea.Cmd) { return m.openSnippetPrompt(), nil })
+	registerAction("Toggle compact rows", func(m Model) (Model, tea.Cmd) { return m.toggleCompact(), nil })
+	registerAction("Toggle radio column of single values", func(m Model) (Model, tea.Cmd) { return m.toggleRadio(), nil })
+	registerAction("Toggle value alignment", func(m Model) (Model, tea.Cmd) { return m.toggleAlignValues(), nil })
+	registerAction("Show values shared by several variables", func(m Model) (Model, tea.Cmd) { return m.toggleSharedValues(), nil })
+	registerAction("Toggle detail panel", func(m Model) (Model, tea.Cmd) { return m.toggleDetail(), nil })
+	registerAction("Toggle value types", func(m Model) (Model, tea.Cmd) { return m.toggleTypes(), nil })
+	registerAction("Reload preferences", Model.reloadPreferences)
+	registerAction("Toggle theme", func(m Model) (Model, tea.Cmd) { return m.toggleTheme(), nil })
+	registerAction("Toggle group by file", func(m Model) (Model, tea.Cmd) { return m.toggleGroupByFile(), nil })
+	registerAction("Cycle sort order", func(m Model) (Model, tea.Cmd) { return m.cycleSortMode(), nil })
+	registerAction("Quit", Model.quit)
+}
+
+// fuzzyMatch reports whether all characters of query appear in order in target
+// (case-insensitive). The returned score is lower for tighter matches.
+func fuzzyMatch(query, target string) (int, bool) {
+	query = strings.ToLower(query)
+	target = strings.ToLower(target)
+
+	score := 0
+	last := -1
+	pos := 0
+	for _, r := range query {
+		idx := strings.IndexRune(target[pos:], r)
+		if idx == -1 {
+			return 0, false
+		}
+		idx += pos
+		if last != -1 {
+			score += idx - last - 1 // Penalize gaps between matched characters
+		} else {
+			score += idx // Penalize matches starting late
+		}
+		last = idx
+		pos = idx + len(string(r))
+	}
+	return score, true
+}
+
+// filterActions returns the actions matching query, best matches first.
+func filterActions(actions []paletteAction, query string) []paletteAction {
+	type scored struct {
+		action paletteAction
+		score  int
+	}
+	var matches []scored
+	for _, action := range actions {
+		if score, ok := fuzzyMatch(query, action.name); ok {
+			matches = append(matches, scored{action, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score < matches[j].score })
+
+	result := make([]paletteAction, len(matches))
+	for i, match := range matches {
+		result[i] = match.action
+	}
+	return result
+}
+
+// openPalette shows the command palette.
+func (m Model) openPalette() Model {
+	m.paletteInput = newTextInput()
+	m.paletteInput.Prompt = "> "
+	m.paletteInput.Placeholder = "Type a command"
+	m.paletteInput.Focus()
+	m.paletteCursor = 0
+	m.showPalette = true
+	return m
+}
+
+// handlePalette handles key presses while the command palette is shown.
+func (m Model) handlePalette(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	matches := filterActions(paletteActions, m.paletteInput.Value())
+
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.showPalette = false
+		return m, nil
+	case "up", "ctrl+p":
+		if m.paletteCursor > 0 {
+			m.paletteCursor--
+		}
+		return m, nil
+	case "down", "ctrl+n":
+		if m.paletteCursor < len(matches)-1 {
+			m.paletteCursor++
+		}
+		return m, nil
+	case "enter":
+		m.showPalette = false
+		if m.paletteCursor < 0 || m.paletteCursor >= len(matches) {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m, cmd = matches[m.paletteCursor].run(m)
+		m.updateViewportContent()
+		return m, cmd
+	}
+
+	var cmd tea.Cmd
+	m.paletteInput, cmd = m.paletteInput.Update(msg)
+	m.paletteCursor = 0
+	return m, cmd
+}
+
+// renderPalette renders the command palette in place of the list, height rows tall.
+func (m *Model) renderPalette(height int) string {
+	var builder strings.Builder
+	builder.WriteString(m.paletteInput.View())
+
+	matches := filterActions(paletteActions, m.paletteInput.Value())
+	for i, action := range matches {
+		if i >= height-1 {
+			break
+		}
+		builder.WriteString("\n")
+		if i == m.paletteCursor {
+			builder.WriteString(m.styles.FocusedLine.Render(iconPointer + action.name))
+		} else {
+			builder.WriteString(m.styles.NormalLine.Render("  " + action.name))
+		}
+	}
+
+	return lipgloss.NewStyle().Width(m.width).Height(height).Render(builder.String())
+}
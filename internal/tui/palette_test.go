@@ -0,0 +1,72 @@
+package tui
+
+import (
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestRegisterAction(t *testing.T) {
+	registered := paletteActions
+	t.Cleanup(func() { paletteActions = registered })
+	ran := false
+	registerAction("Frobnicate the buffer", func(m Model) (Model, tea.Cmd) {
+		ran = true
+		return m, nil
+	})
+	if got := paletteActions[len(paletteActions)-1].name; got != "Frobnicate the buffer" {
+		t.Fatalf("last action is %q, want the registered one", got)
+	}
+
+	m := newTestModel(t, "A=1\n", Options{})
+	m = press(m, ":", "frob", "enter")
+	if !ran {
+		t.Error("selected action didn't run")
+	}
+	if m.showPalette {
+		t.Error("palette still shown after running an action")
+	}
+}
+
+func TestFilterActions(t *testing.T) {
+	noop := func(m Model) (Model, tea.Cmd) { return m, nil }
+	actions := []paletteAction{{"Save", noop}, {"Save as…", noop}, {"Toggle secret masking", noop}, {"Toggle theme", noop}}
+	names := func(actions []paletteAction) []string {
+		var names []string
+		for _, action := range actions {
+			names = append(names, action.name)
+		}
+		return names
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"Save", "Save as…", "Toggle secret masking", "Toggle theme"}},
+		{"SAVE", []string{"Save", "Save as…"}},
+		{"tt", []string{"Toggle theme", "Toggle secret masking"}}, // Tighter match first
+		{"mask", []string{"Toggle secret masking"}},
+		{"xyz", nil},
+	}
+	for _, tt := range tests {
+		if got := names(filterActions(actions, tt.query)); !slices.Equal(got, tt.want) {
+			t.Errorf("filterActions(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestPaletteRunsAction(t *testing.T) {
+	m := newTestModel(t, "A=1\n# A=2\n", Options{})
+	m = press(m, "ctrl+k", "togg them", "enter")
+	if !m.natureTheme {
+		t.Error("theme not toggled from the palette")
+	}
+
+	// Closing the palette runs nothing
+	m = press(m, ":", "togg them", "esc")
+	if m.showPalette || !m.natureTheme {
+		t.Errorf("palette shown %v, nature theme %v after esc", m.showPalette, m.natureTheme)
+	}
+}
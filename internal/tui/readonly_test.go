@@ -0,0 +1,38 @@
+package tui
+
+import (
+	"os"
+	"testing"
+)
+
+func TestReadOnlyRefusesWrites(t *testing.T) {
+	const content = "A=1\n#A=2\n"
+	m := newTestModel(t, content, Options{ReadOnly: true})
+
+	for _, keys := range [][]string{{"ctrl+o"}, {"D"}} {
+		if got := press(m, keys...); got.inputKind != inputNone {
+			t.Errorf("%v opened a prompt in read-only mode", keys)
+		}
+	}
+
+	// Submitting a Save As reached another way still writes nothing
+	updated, cmd := m.submitInput(inputSaveAsPath, m.filePath)
+	if cmd != nil {
+		t.Errorf("Save As returned a command in read-only mode: %v", cmd())
+	}
+	if status := updated.(Model).statusMessage; status != "Read-only: changes are disabled." {
+		t.Errorf("status = %q", status)
+	}
+
+	if _, cmd := m.submitInput(inputExportPath, m.filePath); cmd != nil {
+		t.Error("exporting over the open file was not refused")
+	}
+
+	got, err := os.ReadFile(m.filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != content {
+		t.Errorf("file changed to %q", got)
+	}
+}
@@ -0,0 +1,42 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/taha-yassine/sidem/internal/stats"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// toggleSharedValues shows or hides the list of values shared by several variables.
+func (m Model) toggleSharedValues() Model {
+	m.showSharedValues = !m.showSharedValues
+	return m
+}
+
+// renderSharedValues renders the values shared by several variables in place
+// of the list, height rows tall.
+func (m *Model) renderSharedValues(height int) string {
+	var builder strings.Builder
+	builder.WriteString(m.styles.FileHeader.Render("Values shared by several variables"))
+
+	shared := stats.SharedValues(m.parsedData)
+	if len(shared) == 0 {
+		builder.WriteString("\n" + m.styles.NormalLine.Render("  No two active variables hold the same value."))
+	}
+	for _, sv := range shared {
+		value := sv.Value
+		if m.options.MaskSecrets && !m.peeking() {
+			for _, key := range sv.Keys {
+				if isSecretKey(key, m.options.SecretKeys) {
+					value = iconMasked
+					break
+				}
+			}
+		}
+		builder.WriteString("\n  " + m.styles.ModifiedStatus.Render(value))
+		builder.WriteString("\n    " + m.styles.KeyStyle.Render(strings.Join(sv.Keys, ", ")))
+	}
+
+	return lipgloss.NewStyle().Width(m.width).Height(height).MaxHeight(height).Render(builder.String())
+}
@@ -0,0 +1,59 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sortMode is the order groups are listed in. It only affects the display:
+// lines are always saved at their original position in the file.
+type sortMode int
+
+const (
+	sortFileOrder  sortMode = iota // Order of first appearance in the file
+	sortAscending                  // Keys A–Z
+	sortDescending                 // Keys Z–A
+)
+
+// String returns the label shown in the footer for the mode.
+func (s sortMode) String() string {
+	switch s {
+	case sortAscending:
+		return "A–Z"
+	case sortDescending:
+		return "Z–A"
+	default:
+		return "file order"
+	}
+}
+
+// compareKeys orders keys a and b for the mode, case-insensitively. It returns 0
+// in file order, leaving the groups where they are.
+func (s sortMode) compareKeys(a, b string) int {
+	switch s {
+	case sortAscending:
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	case sortDescending:
+		return strings.Compare(strings.ToLower(b), strings.ToLower(a))
+	default:
+		return 0
+	}
+}
+
+// cycleSortMode switches the list between file order, A–Z and Z–A, keeping the
+// cursor on the focused group.
+func (m Model) cycleSortMode() Model {
+	key := m.focusedGroupKey()
+	m.sortMode = (m.sortMode + 1) % (sortDescending + 1)
+	m.cursor = 0
+	for i, item := range m.getCurrentListItems() {
+		if item.isGroupHeader && m.parsedData.GroupOrder[item.groupIndex] == key {
+			m.cursor = i
+			break
+		}
+	}
+	m.updateViewportContent()
+	m.ensureCursorVisible()
+	m.statusMessage = fmt.Sprintf("Sorted by %s.", m.sortMode)
+	return m
+}
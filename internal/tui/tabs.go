@@ -0,0 +1,240 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// tabBarHeight is the number of rows taken by the tab bar above the open file.
+const tabBarHeight = 1
+
+// tabMsg carries a message produced by the commands of the tab at index, so it
+// is routed back to that tab whichever one is shown.
+type tabMsg struct {
+	index int
+	msg   tea.Msg
+}
+
+// tagCmd wraps cmd so its message is delivered to the tab at index.
+func tagCmd(index int, cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		return tabMsg{index: index, msg: cmd()}
+	}
+}
+
+// Tabs edits several files side by side, one Model per file, switching between
+// them with tab and shift+tab. Each tab keeps its own cursor, changes and
+// watcher; saving only writes the shown file, and quitting asks about every
+// file with unsaved changes.
+type Tabs struct {
+	tabs           []Model
+	active         int  // Index of the shown tab
+	width          int  // Terminal width
+	showQuitPrompt bool // True when asking whether to save the modified files before quitting
+	quitting       bool
+}
+
+// NewTabs creates a tabbed interface over the given models, the first one shown.
+func NewTabs(models ...Model) Tabs {
+	return Tabs{tabs: models}
+}
+
+// Init starts every tab.
+func (t Tabs) Init() tea.Cmd {
+	cmds := make([]tea.Cmd, 0, len(t.tabs))
+	for i, tab := range t.tabs {
+		cmds = append(cmds, tagCmd(i, tab.Init()))
+	}
+	return tea.Batch(cmds...)
+}
+
+// Update routes key presses to the shown tab, and other messages to the tab
+// whose command produced them.
+func (t Tabs) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		t.width = msg.Width
+		size := tea.WindowSizeMsg{Width: msg.Width, Height: msg.Height - tabBarHeight}
+		cmds := make([]tea.Cmd, 0, len(t.tabs))
+		for i := range t.tabs {
+			cmds = append(cmds, t.updateTab(i, size))
+		}
+		return t, tea.Batch(cmds...)
+
+	case tabMsg:
+		switch inner := msg.msg.(type) {
+		case nil:
+			return t, nil
+		case tea.BatchMsg:
+			cmds := make([]tea.Cmd, 0, len(inner))
+			for _, cmd := range inner {
+				cmds = append(cmds, tagCmd(msg.index, cmd))
+			}
+			return t, tea.Batch(cmds...)
+		case tea.QuitMsg:
+			return t.tabQuit()
+		}
+		return t, t.updateTab(msg.index, msg.msg)
+
+	case tea.KeyMsg:
+		if t.showQuitPrompt {
+			return t.handleQuitPrompt(msg)
+		}
+		if t.tabs[t.active].acceptsCommands() {
+			switch msg.String() {
+			case "tab":
+				t.active = (t.active + 1) % len(t.tabs)
+				return t, nil
+			case "shift+tab":
+				t.active = (t.active + len(t.tabs) - 1) % len(t.tabs)
+				return t, nil
+			case "q", "ctrl+c":
+				return t.quit()
+			}
+		}
+	}
+	return t, t.updateTab(t.active, msg)
+}
+
+// updateTab passes msg to the tab at index, returning its tagged command.
+func (t *Tabs) updateTab(index int, msg tea.Msg) tea.Cmd {
+	updated, cmd := t.tabs[index].Update(msg)
+	t.tabs[index] = updated.(Model)
+	return tagCmd(index, cmd)
+}
+
+// unsaved returns the indexes of the tabs with unsaved changes.
+func (t *Tabs) unsaved() []int {
+	var indexes []int
+	for i, tab := range t.tabs {
+		if tab.modified && !tab.options.ReadOnly && !tab.quitting {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// quit quits, asking first whether to save the files with unsaved changes.
+func (t Tabs) quit() (tea.Model, tea.Cmd) {
+	if len(t.unsaved()) > 0 {
+		t.showQuitPrompt = true
+		return t, nil
+	}
+	return t.quitAll()
+}
+
+// tabQuit handles a tab quitting on its own, e.g. once saved after the quit
+// prompt: the program ends when no other tab is still saving or has unsaved
+// changes, which are shown and asked about otherwise.
+func (t Tabs) tabQuit() (tea.Model, tea.Cmd) {
+	for _, tab := range t.tabs {
+		if tab.quittingAfterSave {
+			return t, nil // Wait for the other saves
+		}
+	}
+	if unsaved := t.unsaved(); len(unsaved) > 0 {
+		t.active = unsaved[0]
+		t.showQuitPrompt = true
+		return t, nil
+	}
+	return t.quitAll()
+}
+
+// quitAll stops every tab and ends the program.
+func (t Tabs) quitAll() (tea.Model, tea.Cmd) {
+	for i := range t.tabs {
+		t.tabs[i].quitting = true
+		if t.tabs[i].watcherCancel != nil {
+			t.tabs[i].watcherCancel()
+		}
+	}
+	t.quitting = true
+	return t, tea.Quit
+}
+
+// handleQuitPrompt handles key presses when asked whether to save before quitting.
+func (t Tabs) handleQuitPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y":
+		// Each tab quits once saved, the last one ends the program
+		t.showQuitPrompt = false
+		var cmds []tea.Cmd
+		for _, i := range t.unsaved() {
+			t.tabs[i].statusMessage = "Saving..."
+			t.tabs[i].quittingAfterSave = true
+			cmds = append(cmds, tagCmd(i, t.tabs[i].saveCmd()))
+		}
+		return t, tea.Batch(cmds...)
+	case "n", "N":
+		for _, i := range t.unsaved() {
+			t.tabs[i].quitUnsaved = true
+		}
+		return t.quitAll()
+	case "c", "C", "esc":
+		// Tabs which already quit are resumed
+		t.showQuitPrompt = false
+		var cmds []tea.Cmd
+		for i := range t.tabs {
+			if t.tabs[i].quitting {
+				t.tabs[i].quitting = false
+				cmds = append(cmds, tagCmd(i, t.tabs[i].restartWatcher()))
+			}
+		}
+		return t, tea.Batch(cmds...)
+	}
+	return t, nil
+}
+
+// ExitCode returns the exit status the program should end with once the TUI has
+// quit, the highest of the tabs'.
+func (t Tabs) ExitCode() int {
+	code := 0
+	for _, tab := range t.tabs {
+		code = max(code, tab.ExitCode())
+	}
+	return code
+}
+
+// View renders the tab bar above the shown tab.
+func (t Tabs) View() string {
+	if t.quitting {
+		return ""
+	}
+	return t.renderTabBar() + "\n" + t.tabs[t.active].View()
+}
+
+// renderTabBar renders the open files, the shown one highlighted and modified
+// ones marked, or the quit prompt while it is shown.
+func (t *Tabs) renderTabBar() string {
+	styles := t.tabs[t.active].styles
+	if t.showQuitPrompt {
+		var names []string
+		for _, i := range t.unsaved() {
+			names = append(names, t.tabs[i].filePath)
+		}
+		prompt := fmt.Sprintf("Unsaved changes in %s. Save before quitting? ([Y]es/[N]o/[C]ancel)", strings.Join(names, ", "))
+		return styles.PromptStyle.MaxWidth(t.width).Render(prompt)
+	}
+
+	labels := make([]string, len(t.tabs))
+	for i, tab := range t.tabs {
+		label := " " + tab.filePath
+		if tab.modified {
+			label += "*"
+		}
+		label += " "
+		if i == t.active {
+			labels[i] = styles.FocusedLine.Reverse(true).Render(label)
+		} else {
+			labels[i] = styles.DisabledLine.Render(label)
+		}
+	}
+	bar := strings.Join(labels, " ")
+	return lipgloss.NewStyle().MaxWidth(t.width).Render(bar)
+}
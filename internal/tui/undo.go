@@ -0,0 +1,232 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/taha-yassine/sidem/internal/parser"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// lineValue is the value of a line before a change, with its quoting.
+type lineValue struct {
+	line    *parser.Line
+	value   string
+	raw     string
+	quote   rune
+	content string
+}
+
+// undoEntry is the state of the buffer before a change. Selection and value
+// changes record only the group they affect: its selection and the values of
+// its lines. Changes to the structure of the file, such as adding or deleting
+// lines, record the layout of the whole buffer instead.
+type undoEntry struct {
+	key             string
+	isSelected      bool
+	selectedLineIdx int
+	values          []lineValue
+	layout          *bufferLayout // Set for a structural change, the group fields are then unused
+	modified        bool          // Model.modified before the change
+}
+
+// bufferLayout is the structure of the buffer: its lines in order, each with
+// its content, and its groups in display order.
+type bufferLayout struct {
+	lines     []savedLine
+	groups    []parser.VariableGroup // Lines are copies of the groups' slices
+	managed   *parser.ManagedBlock
+	conflicts []string
+}
+
+// savedLine is a line of the buffer with the state it had.
+type savedLine struct {
+	line  *parser.Line
+	state parser.Line
+}
+
+// snapshotLayout records the structure of the buffer before a structural
+// change to the group key.
+func (m *Model) snapshotLayout(key string) undoEntry {
+	pd := m.parsedData
+	layout := &bufferLayout{
+		lines:     make([]savedLine, len(pd.Lines)),
+		groups:    make([]parser.VariableGroup, 0, len(pd.GroupOrder)),
+		conflicts: slices.Clone(pd.Conflicts),
+	}
+	for i, line := range pd.Lines {
+		layout.lines[i] = savedLine{line: line, state: *line}
+	}
+	for _, k := range pd.GroupOrder {
+		group := *pd.VariableGroups[k]
+		group.Lines = slices.Clone(group.Lines)
+		layout.groups = append(layout.groups, group)
+	}
+	if pd.Managed != nil {
+		managed := *pd.Managed
+		layout.managed = &managed
+	}
+	return undoEntry{key: key, layout: layout, modified: m.modified}
+}
+
+// restoreLayout brings the buffer back to the recorded structure. The lines
+// and groups keep their identity, so references to them stay valid.
+func (m *Model) restoreLayout(layout *bufferLayout) {
+	pd := m.parsedData
+	pd.Lines = make([]*parser.Line, len(layout.lines))
+	for i, saved := range layout.lines {
+		*saved.line = saved.state
+		pd.Lines[i] = saved.line
+	}
+	groups := make(map[string]*parser.VariableGroup, len(layout.groups))
+	pd.GroupOrder = make([]string, 0, len(layout.groups))
+	for _, saved := range layout.groups {
+		group, ok := pd.VariableGroups[saved.Key]
+		if !ok {
+			group = &parser.VariableGroup{}
+		}
+		*group = saved
+		group.Lines = slices.Clone(saved.Lines)
+		groups[saved.Key] = group
+		pd.GroupOrder = append(pd.GroupOrder, saved.Key)
+	}
+	pd.VariableGroups = groups
+	pd.Managed = nil
+	if layout.managed != nil {
+		managed := *layout.managed
+		pd.Managed = &managed
+	}
+	pd.Conflicts = slices.Clone(layout.conflicts)
+}
+
+// snapshot records the current state of the group key.
+func (m *Model) snapshot(key string) undoEntry {
+	entry := undoEntry{key: key, modified: m.modified}
+	group, ok := m.parsedData.VariableGroups[key]
+	if !ok {
+		return entry
+	}
+	entry.isSelected = group.IsSelected
+	entry.selectedLineIdx = group.SelectedLineIdx
+	entry.values = make([]lineValue, len(group.Lines))
+	for i, line := range group.Lines {
+		entry.values[i] = lineValue{line: line, value: line.Value, raw: line.RawValue, quote: line.QuoteType, content: line.OriginalContent}
+	}
+	return entry
+}
+
+// snapshotLike records the current state the way entry was recorded, to
+// move it to the other history stack.
+func (m *Model) snapshotLike(entry undoEntry) undoEntry {
+	if entry.layout != nil {
+		return m.snapshotLayout(entry.key)
+	}
+	return m.snapshot(entry.key)
+}
+
+// restore brings the buffer back to the state recorded in entry.
+func (m *Model) restore(entry undoEntry) {
+	m.modified = entry.modified
+	m.revision++
+	if entry.layout != nil {
+		m.restoreLayout(entry.layout)
+		return
+	}
+	group, ok := m.parsedData.VariableGroups[entry.key]
+	if !ok {
+		return
+	}
+	group.IsSelected = entry.isSelected
+	group.SelectedLineIdx = entry.selectedLineIdx
+	for _, v := range entry.values {
+		v.line.Value = v.value
+		v.line.RawValue = v.raw
+		v.line.QuoteType = v.quote
+		v.line.OriginalContent = v.content
+	}
+}
+
+// pushUndo records the state of a group before a change, making the change
+// undoable. Changes undone so far can no longer be redone.
+func (m *Model) pushUndo(entry undoEntry) {
+	m.undoStack = append(m.undoStack[:len(m.undoStack):len(m.undoStack)], entry)
+	m.redoStack = nil
+}
+
+// forgetHistory clears the undo and redo history, after the buffer was
+// replaced or its keys renamed, which the recorded states no longer apply to.
+func (m *Model) forgetHistory() {
+	m.undoStack = nil
+	m.redoStack = nil
+}
+
+// historySaved records that the buffer was saved: going back or forth in the
+// history from here leads to unsaved states.
+func (m *Model) historySaved() {
+	m.undoStack = markUnsaved(m.undoStack)
+	m.redoStack = markUnsaved(m.redoStack)
+}
+
+// markUnsaved returns a copy of entries all flagged as modified.
+func markUnsaved(entries []undoEntry) []undoEntry {
+	if len(entries) == 0 {
+		return entries
+	}
+	marked := make([]undoEntry, len(entries))
+	for i, entry := range entries {
+		entry.modified = true
+		marked[i] = entry
+	}
+	return marked
+}
+
+// undo reverts the last recorded change.
+func (m Model) undo() (Model, tea.Cmd) {
+	if m.refuseReadOnly() {
+		return m, nil
+	}
+	if len(m.undoStack) == 0 {
+		m.statusMessage = "Nothing to undo."
+		return m, nil
+	}
+	entry := m.undoStack[len(m.undoStack)-1]
+	m.undoStack = m.undoStack[:len(m.undoStack)-1]
+	m.redoStack = append(m.redoStack[:len(m.redoStack):len(m.redoStack)], m.snapshotLike(entry))
+	m.restore(entry)
+	m.statusMessage = fmt.Sprintf("Undid change to %s.", entry.key)
+	return m.afterHistoryMove()
+}
+
+// redo applies again the last undone change.
+func (m Model) redo() (Model, tea.Cmd) {
+	if m.refuseReadOnly() {
+		return m, nil
+	}
+	if len(m.redoStack) == 0 {
+		m.statusMessage = "Nothing to redo."
+		return m, nil
+	}
+	entry := m.redoStack[len(m.redoStack)-1]
+	m.redoStack = m.redoStack[:len(m.redoStack)-1]
+	m.undoStack = append(m.undoStack[:len(m.undoStack):len(m.undoStack)], m.snapshotLike(entry))
+	m.restore(entry)
+	m.statusMessage = fmt.Sprintf("Redid change to %s.", entry.key)
+	return m.afterHistoryMove()
+}
+
+// afterHistoryMove redraws the list after an undo or redo, scheduling an
+// auto-save if the buffer is left with unsaved changes.
+func (m Model) afterHistoryMove() (Model, tea.Cmd) {
+	if group := m.occurrencesGroup(); group == nil {
+		m.occurrencesKey = ""
+	} else {
+		m.occurrenceCursor = min(m.occurrenceCursor, len(group.Lines)-1)
+	}
+	m.clampCursor()
+	m.updateViewportContent()
+	if !m.modified {
+		return m, nil
+	}
+	return m, m.markModified()
+}
@@ -0,0 +1,56 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/taha-yassine/sidem/internal/parser"
+)
+
+func TestUndoStructuralChanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		keys    []string
+	}{
+		{"add", "A=1\n", []string{"a", "NEW", "enter", "2", "enter"}},
+		{"delete variable", "A=1\n# A=2\nB=3\n", []string{"d", "y"}},
+		{"delete occurrence", "A=1\n# A=2\nB=3\n", []string{"down", "down", "d", "y"}},
+		{"mark required", "A=1\nB=2\n", []string{"!"}},
+		{"sort", "A=1 # b\n# A=2 # a\n", []string{"O"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestModel(t, tt.content, Options{})
+			m = press(m, tt.keys...)
+			changed := parser.RenderLines(m.parsedData.Lines, m.parsedData)
+			if changed == tt.content {
+				t.Fatalf("%v didn't change the buffer: %s", tt.keys, m.statusMessage)
+			}
+
+			m = press(m, "u")
+			if got := parser.RenderLines(m.parsedData.Lines, m.parsedData); got != tt.content {
+				t.Errorf("undo left %q, want %q", got, tt.content)
+			}
+			if m.modified {
+				t.Error("undoing back to the saved state left the buffer modified")
+			}
+			reparsed, err := parser.Parse(strings.NewReader(tt.content), m.filePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := m.parsedData.GroupOrder, reparsed.GroupOrder; !slices.Equal(got, want) {
+				t.Errorf("undo left groups %v, want %v", got, want)
+			}
+
+			m = press(m, "ctrl+r")
+			if got := parser.RenderLines(m.parsedData.Lines, m.parsedData); got != changed {
+				t.Errorf("redo left %q, want %q", got, changed)
+			}
+			if !m.modified {
+				t.Error("redo left the buffer unmodified")
+			}
+		})
+	}
+}
@@ -1,15 +1,20 @@
 package tui
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/taha-yassine/sidem/internal/clipboard"
+	"github.com/taha-yassine/sidem/internal/config"
+	"github.com/taha-yassine/sidem/internal/secrets"
 	"github.com/taha-yassine/sidem/internal/watcher"
 
 	"github.com/taha-yassine/sidem/internal/parser"
 
-	"github.com/atotto/clipboard"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -17,9 +22,20 @@ import (
 
 // --- Custom Message Types (errMsg, saveSuccessMsg defined in actions.go) ---
 
+// peekDuration is how long masked values stay revealed after a peek.
+const peekDuration = 5 * time.Second
+
+// changedHighlightDuration is how long variables changed on disk stay highlighted after a reload.
+const changedHighlightDuration = 4 * time.Second
+
+// flashDuration is how long a value just made active is flashed.
+const flashDuration = 300 * time.Millisecond
+
 type (
 	clearStatusMsg     struct{ originalMsg string }
-	confirmedReloadMsg struct{}
+	peekEndMsg         struct{}
+	highlightEndMsg    struct{}
+	confirmedReloadMsg struct{ path string }
 	fileReloadedMsg    struct {
 		parsedData *parser.ParsedData
 	}
@@ -35,33 +51,57 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.height = msg.Height
 		m.width = msg.Width
-		headerHeight := lipgloss.Height(m.renderHeader())
-		footerHeight := lipgloss.Height(m.renderFooter())
-		if m.viewport.Width == 0 || m.viewport.Height == 0 {
-			m.viewport = viewport.New(m.width, m.height-headerHeight-footerHeight)
-			m.viewport.YPosition = headerHeight
-		} else {
-			m.viewport.Width = m.width
-			m.viewport.Height = m.height - headerHeight - footerHeight
-		}
+		m.resizeViewport()
 		m.updateViewportContent()
 		m.ensureCursorVisible()
 
+	case saveBlockedMsg:
+		m.unsafeKeys = make(map[string]bool)
+		for _, key := range msg.keys {
+			m.unsafeKeys[key] = true
+		}
+		// The keys are those of the normalized copy, flag the buffer's own
+		for _, key := range m.parsedData.GroupOrder {
+			if m.unsafeKeys[m.options.KeyCase.Apply(key)] {
+				m.unsafeKeys[key] = true
+			}
+		}
+		m.statusMessage = fmt.Sprintf("Error: save blocked, %d variable(s) would not round-trip: %s", len(msg.keys), strings.Join(msg.keys, ", "))
+		m.quittingAfterSave = false
+		m.showQuitPrompt = false
+
+	case orphanedLinesMsg:
+		m.showQuitPrompt = false
+		m.showOrphanPrompt = true
+		m.orphanCount = msg.count
+		m.statusMessage = ""
+
 	case saveSuccessMsg:
-		m.modified = false
+		if m.adoptSave(msg.from) {
+			m.modified = false
+			m.historySaved()
+			m.savedActive = activeValues(m.parsedData)
+		} // Otherwise changed during the save, the buffer stays modified
+		m.unsafeKeys = nil
+		// The write triggers the watcher, remember it so it isn't treated as an external change
+		m.writtenHash = msg.hash
+		m.hasWrittenHash = true
+		summary := fmt.Sprintf("Wrote %d lines (%d active changed) to %s", msg.lines, msg.changed, m.filePath)
+		if msg.autosave {
+			cmd = m.setStatus("Auto-saved: " + summary)
+			cmds = append(cmds, cmd)
+			break
+		}
 		if m.quittingAfterSave {
 			m.quitting = true
 			m.quittingAfterSave = false
-			m.statusMessage = "Saved successfully! Quitting..."
+			m.statusMessage = summary + ". Quitting..."
 			if m.watcherCancel != nil {
 				m.watcherCancel()
 			}
 			return m, tea.Quit
 		}
-		m.statusMessage = "Saved successfully!"
-		cmd = tea.Tick(2*time.Second, func(t time.Time) tea.Msg {
-			return clearStatusMsg{originalMsg: "Saved successfully!"}
-		})
+		cmd = m.setStatus(summary)
 		cmds = append(cmds, cmd)
 
 	case errMsg:
@@ -70,19 +110,59 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.showQuitPrompt = false
 		m.showReloadPrompt = false
 
+	case autosaveMsg:
+		// Only the latest scheduled auto-save fires, earlier ones are debounced
+		if msg.gen == m.autosaveGen && m.modified && !m.showQuitPrompt && !m.showReloadPrompt {
+			cmds = append(cmds, m.autosaveCmd())
+		}
+
+	case permissionDeniedMsg:
+		m.quittingAfterSave = false
+		m.showQuitPrompt = false
+		// Offer to save elsewhere, the prompt explains what happened
+		prompt := fmt.Sprintf("Permission denied writing %s — try running with appropriate permissions or use Save As:", msg.path)
+		m = m.openInput(inputSaveAsPath, prompt, "path/to/.env", "")
+
+	case savedAsMsg:
+		m = m.retarget(msg.path)
+		if m.adoptSave(msg.from) {
+			m.modified = false
+			m.historySaved()
+			m.savedActive = activeValues(m.parsedData)
+		}
+		m.unsafeKeys = nil
+		m.writtenHash = msg.hash
+		m.hasWrittenHash = true
+		cmd = m.setStatus(fmt.Sprintf("Saved as %s", msg.path))
+		cmds = append(cmds, cmd, m.restartWatcher())
+		if msg.reload {
+			cmds = append(cmds, m.reloadFileCmd(m.filePath))
+		}
+
+	case duplicatedMsg:
+		cmd = m.setStatus(fmt.Sprintf("Duplicated to %s", msg.path))
+		cmds = append(cmds, cmd)
+
+	case exportedMsg:
+		cmd = m.setStatus(fmt.Sprintf("Exported %d variable(s) to %s", msg.count, msg.path))
+		cmds = append(cmds, cmd)
+
 	case clearStatusMsg:
 		if m.statusMessage == msg.originalMsg {
 			m.statusMessage = ""
 		}
 
 	case watcher.FileChangedMsg:
-		if m.modified {
+		path := msg.Path
+		if path == m.filePath && m.hasWrittenHash && fileHasHash(m.filePath, m.writtenHash) {
+			// Self-triggered by our own save, the file already matches the buffer
+		} else if m.modified {
 			m.showReloadPrompt = true
-			m.pendingReloadAction = func() tea.Msg { return confirmedReloadMsg{} }
+			m.pendingReloadAction = func() tea.Msg { return confirmedReloadMsg{path: path} }
 			m.statusMessage = ""
 		} else {
 			m.statusMessage = "File changed, reloading..."
-			cmd = m.reloadFileCmd()
+			cmd = m.reloadFileCmd(path)
 			cmds = append(cmds, cmd)
 		}
 		if m.watcher != nil {
@@ -99,26 +179,52 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.statusMessage = "Reloading..."
 		m.showReloadPrompt = false
 		m.modified = false
-		cmd = m.reloadFileCmd()
+		cmd = m.reloadFileCmd(msg.path)
 		cmds = append(cmds, cmd)
 
 	case fileReloadedMsg:
+		m.changedOnDisk = changedKeys(m.parsedData, msg.parsedData, time.Now().Add(changedHighlightDuration))
+		if len(m.changedOnDisk) > 0 {
+			cmds = append(cmds, tea.Tick(changedHighlightDuration, func(t time.Time) tea.Msg {
+				return highlightEndMsg{}
+			}))
+		}
+		previous := m.parsedData
 		m.parsedData = msg.parsedData
+		m.forgetHistory()
+		m.savedActive = activeValues(m.parsedData)
+		m.resolvedLines = nil
+		if m.options.ShowEnv {
+			m.envOverrides = detectEnvOverrides(m.parsedData, os.Environ())
+		}
 		m.modified = false
+		if carrySelection(previous, m.parsedData) {
+			// Choices made in the TUI for untouched variables survive the reload
+			cmds = append(cmds, m.markModified())
+		}
 		m.cursor = 0
 		m.focusIndex = 0
-		m.statusMessage = "File reloaded successfully."
+		m.resizeViewport() // The header grows with invalid lines
 		m.updateViewportContent()
 		m.ensureCursorVisible()
-		cmd = tea.Tick(2*time.Second, func(t time.Time) tea.Msg {
-			return clearStatusMsg{originalMsg: "File reloaded successfully."}
-		})
+		cmd = m.setStatus("File reloaded successfully.")
 		cmds = append(cmds, cmd)
 
+	case mergeLoadedMsg:
+		m = m.openMerge(msg.parsedData)
+
+	case highlightEndMsg:
+		// Nothing to do, re-rendering drops the expired highlights
+
+	case peekEndMsg:
+		// Nothing to do, re-rendering masks the values again once peekUntil is past
+
 	case tea.KeyMsg:
 		if m.statusMessage != "" && !strings.HasPrefix(m.statusMessage, "Error:") {
 			m.statusMessage = ""
 		}
+		// Any key press ends a peek
+		m.peekUntil = time.Time{}
 
 		if m.showQuitPrompt {
 			return m.handleQuitPrompt(msg)
@@ -126,64 +232,179 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.showReloadPrompt {
 			return m.handleReloadPrompt(msg)
 		}
+		if m.showOrphanPrompt {
+			return m.handleOrphanPrompt(msg)
+		}
+		if m.pendingDelete != nil {
+			return m.handleDeletePrompt(msg)
+		}
+		if m.inputKind != inputNone {
+			return m.handleInputPrompt(msg)
+		}
+		if m.showMerge {
+			return m.handleMerge(msg)
+		}
+		if m.showPalette {
+			return m.handlePalette(msg)
+		}
+		if m.occurrencesKey != "" {
+			return m.handleOccurrences(msg)
+		}
+		if m.pendingBookmark != bookmarkNone {
+			return m.handleBookmark(msg)
+		}
+		if m.showSharedValues && msg.String() == "esc" {
+			m.showSharedValues = false
+			return m, nil
+		}
 
 		switch msg.String() {
 		case "ctrl+c", "q":
-			if m.modified {
-				m.showQuitPrompt = true
-				return m, nil
-			}
-			m.quitting = true
-			if m.watcherCancel != nil {
-				m.watcherCancel()
-			}
-			return m, tea.Quit
+			return m.quit()
 
 		case "up", "k":
 			m = m.moveUp()
 		case "down", "j":
 			m = m.moveDown()
 
-		case " ": // Spacebar
-			var changed bool
-			m, changed = m.toggleSelection()
-			if changed {
-				m.modified = true
+		case " ", "enter": // Spacebar, Enter as an alias
+			m, cmd = m.toggle()
+			cmds = append(cmds, cmd)
+
+		case "c": // Compact single-occurrence groups
+			m = m.toggleCompact()
+
+		case "H": // Hide the radio column of single-occurrence groups
+			m = m.toggleRadio()
+
+		case "T": // Show the inferred type of values
+			m = m.toggleTypes()
+
+		case "t": // Switch between the default and nature themes
+			m = m.toggleTheme()
+
+		case "I": // Show the focused variable's details next to the list
+			m = m.toggleDetail()
+
+		case "=": // List values shared by several variables
+			m = m.toggleSharedValues()
+
+		case "F": // Group list by source file
+			m = m.toggleGroupByFile()
+
+		case "s": // Cycle the list order: file order, A–Z, Z–A
+			m = m.cycleSortMode()
+
+		case "D": // Duplicate the buffer to a new environment file
+			m = m.openDuplicatePrompt()
+
+		case "ctrl+o": // Save As
+			m = m.openSaveAsPrompt()
+
+		case "w": // Export the active variables to a flat file
+			m = m.openExportPrompt()
+
+		case "u": // Undo the last selection or value change
+			m, cmd = m.undo()
+			cmds = append(cmds, cmd)
+
+		case "ctrl+r": // Redo the last undone change
+			m, cmd = m.redo()
+			cmds = append(cmds, cmd)
+
+		case "/": // Filter the list by key
+			m = m.openFilter()
+
+		case "esc": // Restore the full list
+			if m.filter != "" {
+				m = m.clearFilter()
 			}
 
+		case "M": // Mask sensitive values, or reveal them all
+			m, cmd = m.toggleMasking()
+			cmds = append(cmds, cmd)
+
+		case "p": // Reveal masked values for a moment
+			m, cmd = m.peek()
+			cmds = append(cmds, cmd)
+
+		case "P": // Promote the focused group's --env override
+			m, cmd = m.promoteOverride()
+			cmds = append(cmds, cmd)
+
+		case "e": // Edit the focused value in place
+			m = m.openValueEditor()
+
+		case "a": // Add a variable, or an occurrence of an existing one
+			m = m.openNewVariablePrompt()
+
+		case "d": // Delete the focused occurrence, or the whole variable from its header
+			m = m.openDeletePrompt()
+
+		case "L": // Go to a file line number
+			m = m.openGotoLinePrompt()
+
+		case "o": // Show the focused group's occurrences as a table
+			m = m.openOccurrences()
+
+		case "O": // Sort the focused group's occurrences by inline comment
+			m, cmd = m.sortOccurrences()
+			cmds = append(cmds, cmd)
+
+		case "m": // Bookmark the focused variable under a letter
+			m = m.startBookmark(bookmarkSet)
+
+		case "'": // Jump to a bookmarked variable
+			m = m.startBookmark(bookmarkJump)
+
+		case "x": // Cycle the active line of a variable uncommented several times
+			m, cmd = m.cycleConflict()
+			cmds = append(cmds, cmd)
+
+		case "!": // Mark the focused variable as required or optional
+			m, cmd = m.toggleRequired()
+			cmds = append(cmds, cmd)
+
+		case "i": // Insert a snippet into the focused value
+			m = m.openSnippetPrompt()
+
+		case "ctrl+k", ":": // Command palette
+			m = m.openPalette()
+
+		case "ctrl+l": // Re-read the configuration file
+			m, cmd = m.reloadPreferences()
+			cmds = append(cmds, cmd)
+
 		case "ctrl+s":
-			if m.modified {
-				m.statusMessage = "Saving..."
-				cmd = m.saveCmd()
-				cmds = append(cmds, cmd)
-			} else {
-				m.statusMessage = "No changes to save."
-				cmd = tea.Tick(2*time.Second, func(t time.Time) tea.Msg {
-					return clearStatusMsg{originalMsg: "No changes to save."}
-				})
-				cmds = append(cmds, cmd)
-			}
+			m, cmd = m.save()
+			cmds = append(cmds, cmd)
+
+		case "S": // Save without a backup or the no-changes check
+			m, cmd = m.fastSave()
+			cmds = append(cmds, cmd)
+
+		case "r": // Show the focused value resolved or literal
+			m = m.toggleResolved()
+
+		case "R": // Copy a reference to the focused secret instead of its value
+			m, cmd = m.copyReference()
+			cmds = append(cmds, cmd)
+
+		case "C": // Copy the absolute path of the open file
+			m, cmd = m.copyFilePath()
+			cmds = append(cmds, cmd)
 
 		case "y": // Copy selected line content
-			textToCopy := m.getSelectedLineContent()
-			if textToCopy != "" {
-				err := clipboard.WriteAll(textToCopy)
-				if err != nil {
-					m.statusMessage = fmt.Sprintf("Error copying: %v", err)
-				} else {
-					m.statusMessage = "Copied to clipboard!"
-					cmd = tea.Tick(2*time.Second, func(t time.Time) tea.Msg {
-						return clearStatusMsg{originalMsg: "Copied to clipboard!"}
-					})
-					cmds = append(cmds, cmd)
-				}
-			} else {
-				m.statusMessage = "The selected line is empty."
-				cmd = tea.Tick(2*time.Second, func(t time.Time) tea.Msg {
-					return clearStatusMsg{originalMsg: "The selected line is empty."}
-				})
-				cmds = append(cmds, cmd)
-			}
+			m, cmd = m.copySelected()
+			cmds = append(cmds, cmd)
+
+		case "Y": // Copy the focused group's active line as KEY=value
+			m, cmd = m.copyPair()
+			cmds = append(cmds, cmd)
+
+		case "A": // Copy every occurrence of the focused group
+			m, cmd = m.copyOccurrences()
+			cmds = append(cmds, cmd)
 		}
 	}
 
@@ -192,6 +413,428 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// --- Actions shared by key bindings and the command palette ---
+
+// quit exits the program, asking to save first if there are unsaved changes.
+func (m Model) quit() (Model, tea.Cmd) {
+	if m.modified && !m.options.ReadOnly {
+		m.showQuitPrompt = true
+		return m, nil
+	}
+	m.quitting = true
+	if m.watcherCancel != nil {
+		m.watcherCancel()
+	}
+	return m, tea.Quit
+}
+
+// refuseReadOnly reports whether the buffer is open read-only, telling the user
+// the change they asked for is disabled.
+func (m *Model) refuseReadOnly() bool {
+	if !m.options.ReadOnly {
+		return false
+	}
+	m.statusMessage = "Read-only: changes are disabled."
+	return true
+}
+
+// ExitCodeUnsaved is the exit status used with ErrorOnUnsavedQuit when
+// quitting without saving changes.
+const ExitCodeUnsaved = 3
+
+// ExitCode returns the exit status the program should end with once the TUI has quit.
+func (m Model) ExitCode() int {
+	if m.options.ErrorOnUnsavedQuit && m.quitUnsaved {
+		return ExitCodeUnsaved
+	}
+	return 0
+}
+
+// toggle toggles the focused group or selects the focused value.
+func (m Model) toggle() (Model, tea.Cmd) {
+	if m.refuseReadOnly() {
+		return m, nil
+	}
+	entry := m.snapshot(m.focusedGroupKey())
+	m, changed := m.toggleSelection()
+	if !changed {
+		return m, nil
+	}
+	m.pushUndo(entry)
+	return m, tea.Batch(m.flashActive(m.focusedGroupKey()), m.markModified())
+}
+
+// flashActive briefly highlights the active line of the group key, so the eye
+// follows a change of active value. It returns the command clearing the flash.
+func (m *Model) flashActive(key string) tea.Cmd {
+	group, ok := m.parsedData.VariableGroups[key]
+	if m.options.NoFlash || !ok || !group.IsSelected || group.SelectedLineIdx < 0 {
+		return nil
+	}
+
+	// Copy on write, dropping expired flashes
+	now := time.Now()
+	flashed := map[*parser.Line]time.Time{group.Lines[group.SelectedLineIdx]: now.Add(flashDuration)}
+	for line, until := range m.flashed {
+		if _, set := flashed[line]; !set && now.Before(until) {
+			flashed[line] = until
+		}
+	}
+	m.flashed = flashed
+	return tea.Tick(flashDuration, func(t time.Time) tea.Msg {
+		return highlightEndMsg{}
+	})
+}
+
+// peek temporarily reveals all masked values.
+func (m Model) peek() (Model, tea.Cmd) {
+	if !m.options.MaskSecrets {
+		m.statusMessage = "No values are masked."
+		return m, nil
+	}
+	m.peekUntil = time.Now().Add(peekDuration)
+	return m, tea.Tick(peekDuration, func(t time.Time) tea.Msg {
+		return peekEndMsg{}
+	})
+}
+
+// promoteOverride writes the focused group's launch override into its active
+// line, turning it into a regular (unsaved) change.
+func (m Model) promoteOverride() (Model, tea.Cmd) {
+	if m.refuseReadOnly() {
+		return m, nil
+	}
+	if m.focusIndex < 0 || m.focusIndex >= len(m.parsedData.GroupOrder) {
+		return m, nil
+	}
+	key := m.parsedData.GroupOrder[m.focusIndex]
+	value, ok := m.overrides[key]
+	if !ok {
+		m.statusMessage = fmt.Sprintf("%s has no override to promote.", key)
+		return m, nil
+	}
+
+	group := m.parsedData.VariableGroups[key]
+	if group.SelectedLineIdx < 0 {
+		return m, nil
+	}
+	m.pushUndo(m.snapshot(key))
+	group.IsSelected = true
+	group.Lines[group.SelectedLineIdx].SetValue(value)
+
+	// Copy on write, the map is shared with the Options
+	overrides := make(map[string]string, len(m.overrides))
+	for k, v := range m.overrides {
+		if k != key {
+			overrides[k] = v
+		}
+	}
+	m.overrides = overrides
+	m.statusMessage = fmt.Sprintf("Promoted override of %s.", key)
+	return m, m.markModified()
+}
+
+// toggleRequired marks the focused variable as required or optional with a
+// "# sidem:required" comment, which the check command can enforce.
+func (m Model) toggleRequired() (Model, tea.Cmd) {
+	if m.refuseReadOnly() {
+		return m, nil
+	}
+	key := m.focusedGroupKey()
+	if key == "" {
+		m.statusMessage = "Focus a variable to mark it as required."
+		return m, nil
+	}
+	required := !m.parsedData.RequiredKeys()[key]
+	m.pushUndo(m.snapshotLayout(key))
+	m.parsedData.SetRequired(key, required)
+	if required {
+		m.statusMessage = fmt.Sprintf("%s marked as required.", key)
+	} else {
+		m.statusMessage = fmt.Sprintf("%s marked as optional.", key)
+	}
+	return m, m.markModified()
+}
+
+// sortOccurrences sorts the occurrences of the focused group by their inline comment label.
+func (m Model) sortOccurrences() (Model, tea.Cmd) {
+	if m.refuseReadOnly() {
+		return m, nil
+	}
+	listItems := m.getCurrentListItems()
+	if m.cursor < 0 || m.cursor >= len(listItems) || !listItems[m.cursor].isGroupHeader {
+		m.statusMessage = "Focus a group header to sort its occurrences."
+		return m, nil
+	}
+	key := m.parsedData.GroupOrder[listItems[m.cursor].groupIndex]
+	entry := m.snapshotLayout(key)
+	if err := m.parsedData.SortGroupByComment(key); err != nil {
+		m.statusMessage = fmt.Sprintf("Error: %v", err)
+		return m, nil
+	}
+	m.pushUndo(entry)
+	m.statusMessage = fmt.Sprintf("Sorted occurrences of %s by comment.", key)
+	return m, m.markModified()
+}
+
+// openGotoLinePrompt asks for the file line number to jump to.
+func (m Model) openGotoLinePrompt() Model {
+	return m.openInput(inputGotoLine, "Go to line:", "42", "")
+}
+
+// toggleRadio shows or hides the radio column of single-occurrence groups,
+// where there is nothing to choose.
+func (m Model) toggleRadio() Model {
+	m.hideRadio = !m.hideRadio
+	m.updateViewportContent()
+	return m
+}
+
+// toggleCompact switches between one row per value and single-occurrence groups on one row.
+func (m Model) toggleCompact() Model {
+	m.compact = !m.compact
+	m.updateViewportContent()
+	m.ensureCursorVisible()
+	return m
+}
+
+// toggleAlignValues switches the column alignment of compact rows' values.
+func (m Model) toggleAlignValues() Model {
+	m.alignValues = !m.alignValues
+	if m.alignValues && !m.compact {
+		// Alignment only applies to compact rows
+		m = m.toggleCompact()
+	}
+	return m
+}
+
+// toggleTypes shows or hides the value type column.
+func (m Model) toggleTypes() Model {
+	m.showTypes = !m.showTypes
+	m.updateViewportContent()
+	return m
+}
+
+// toggleMasking switches the masking of sensitive values on or off, e.g.
+// before sharing the screen. Keys masked by the front matter stay masked.
+func (m Model) toggleMasking() (Model, tea.Cmd) {
+	m.options.MaskSecrets = !m.options.MaskSecrets
+	m.peekUntil = time.Time{}
+	m.updateViewportContent()
+	if m.options.MaskSecrets {
+		cmd := m.setStatus("Masking sensitive values.")
+		return m, cmd
+	}
+	cmd := m.setStatus("Showing all values.")
+	return m, cmd
+}
+
+// toggleTheme switches between the default and nature styles.
+func (m Model) toggleTheme() Model {
+	m.natureTheme = !m.natureTheme
+	m.styles = themeStyles(m.natureTheme)
+	m.updateViewportContent()
+	return m
+}
+
+// reloadPreferences re-reads the configuration file and applies it live.
+// Settings of the file's front matter still take precedence.
+func (m Model) reloadPreferences() (Model, tea.Cmd) {
+	cfg, err := config.Load(m.options.ConfigPath)
+	if err != nil {
+		m.statusMessage = fmt.Sprintf("Error: %v", err)
+		return m, nil
+	}
+	m.options = m.options.WithConfig(cfg)
+	m.natureTheme = m.options.Theme == "nature"
+	m.styles = themeStyles(m.natureTheme)
+	m = m.applyFrontMatter()
+	m.updateViewportContent()
+	cmd := m.setStatus(fmt.Sprintf("Preferences reloaded from %s", m.options.ConfigPath))
+	return m, cmd
+}
+
+// toggleGroupByFile switches between the plain list and the per-file sections.
+func (m Model) toggleGroupByFile() Model {
+	m.groupByFile = !m.groupByFile
+	m.cursor = 0
+	m.updateViewportContent()
+	m.ensureCursorVisible()
+	return m
+}
+
+// openDuplicatePrompt asks for the environment name to duplicate the buffer to.
+func (m Model) openDuplicatePrompt() Model {
+	if m.refuseReadOnly() {
+		return m
+	}
+	return m.openInput(inputDuplicateSuffix, fmt.Sprintf("Duplicate to %s.", m.filePath), "staging", "")
+}
+
+// openExportPrompt asks for the path to export the active variables to.
+func (m Model) openExportPrompt() Model {
+	return m.openInput(inputExportPath, "Export active variables to:", "path/to/file", m.filePath+".export")
+}
+
+// openSaveAsPrompt asks for the path to save the buffer to.
+func (m Model) openSaveAsPrompt() Model {
+	if m.refuseReadOnly() {
+		return m
+	}
+	return m.openInput(inputSaveAsPath, "Save as:", "path/to/.env", m.filePath)
+}
+
+// save writes pending changes to disk, or creates the file if it doesn't exist yet.
+func (m Model) save() (Model, tea.Cmd) {
+	if m.refuseReadOnly() {
+		return m, nil
+	}
+	if _, err := os.Stat(m.filePath); !m.modified && err == nil {
+		cmd := m.setStatus("No changes to save.")
+		return m, cmd
+	}
+	m.statusMessage = "Saving..."
+	return m, m.saveCmd()
+}
+
+// fastSave writes the buffer to disk without backing it up, even if unchanged.
+func (m Model) fastSave() (Model, tea.Cmd) {
+	if m.refuseReadOnly() {
+		return m, nil
+	}
+	m.statusMessage = "Saving..."
+	return m, m.fastSaveCmd()
+}
+
+// copySelected copies the focused key or value to the clipboard.
+func (m Model) copySelected() (Model, tea.Cmd) {
+	textToCopy := m.getSelectedLineContent()
+	if textToCopy == "" {
+		cmd := m.setStatus("The selected line is empty.")
+		return m, cmd
+	}
+	if err := clipboard.Write(m.options.Clipboard, textToCopy); err != nil {
+		m.statusMessage = fmt.Sprintf("Error copying: %v", err)
+		return m, nil
+	}
+	cmd := m.setStatus("Copied to clipboard!")
+	return m, cmd
+}
+
+// copyPair copies the active line of the focused group as KEY=value, the value
+// quoted when needed, ready to be pasted into a terminal.
+func (m Model) copyPair() (Model, tea.Cmd) {
+	key := m.focusedGroupKey()
+	if key == "" {
+		m.statusMessage = "Focus a variable to copy it as KEY=value."
+		return m, nil
+	}
+	group := m.parsedData.VariableGroups[key]
+	if !group.IsSelected || group.SelectedLineIdx < 0 || group.SelectedLineIdx >= len(group.Lines) {
+		m.statusMessage = fmt.Sprintf("%s is inactive, select a value to copy it.", key)
+		return m, nil
+	}
+	pair := key + "=" + parser.QuoteValue(group.Lines[group.SelectedLineIdx].Value)
+	if err := clipboard.Write(m.options.Clipboard, pair); err != nil {
+		m.statusMessage = fmt.Sprintf("Error copying: %v", err)
+		return m, nil
+	}
+	cmd := m.setStatus(fmt.Sprintf("Copied %s=…", key))
+	return m, cmd
+}
+
+// copyOccurrences copies every occurrence of the focused group as .env lines,
+// with their inline comments, the inactive ones commented out, so the whole
+// set of alternatives can be pasted into another file.
+func (m Model) copyOccurrences() (Model, tea.Cmd) {
+	key := m.focusedGroupKey()
+	if key == "" {
+		m.statusMessage = "Focus a variable to copy its occurrences."
+		return m, nil
+	}
+	if err := clipboard.Write(m.options.Clipboard, m.occurrencesText(key)); err != nil {
+		m.statusMessage = fmt.Sprintf("Error copying: %v", err)
+		return m, nil
+	}
+	cmd := m.setStatus(fmt.Sprintf("Copied %d occurrence(s) of %s", len(m.parsedData.VariableGroups[key].Lines), key))
+	return m, cmd
+}
+
+// occurrencesText returns the text copyOccurrences copies for key.
+func (m *Model) occurrencesText(key string) string {
+	return parser.RenderLines(m.parsedData.VariableGroups[key].Lines, m.parsedData)
+}
+
+// toggleResolved switches the focused value between its literal form and its
+// form with ${VAR} references resolved, for that line only.
+func (m Model) toggleResolved() Model {
+	line := m.focusedLine()
+	if line == nil {
+		m.statusMessage = "Focus a value line to resolve it."
+		return m
+	}
+	if !strings.Contains(line.Value, "$") {
+		m.statusMessage = "The focused value has no reference to resolve."
+		return m
+	}
+
+	// Copy on write, the map is shared with previous models
+	resolved := make(map[*parser.Line]bool, len(m.resolvedLines)+1)
+	for l, on := range m.resolvedLines {
+		resolved[l] = on
+	}
+	resolved[line] = !resolved[line]
+	m.resolvedLines = resolved
+	m.updateViewportContent()
+	return m
+}
+
+// copyReference copies a reference to the focused secret variable (e.g. ${KEY})
+// rather than its plaintext value.
+func (m Model) copyReference() (Model, tea.Cmd) {
+	listItems := m.getCurrentListItems()
+	if m.cursor < 0 || m.cursor >= len(listItems) || listItems[m.cursor].groupIndex < 0 {
+		return m, nil
+	}
+	item := listItems[m.cursor]
+	key := m.parsedData.GroupOrder[item.groupIndex]
+	if _, detected := secrets.Detect(item.value); !detected && !isSecretKey(key, m.options.SecretKeys) {
+		m.statusMessage = fmt.Sprintf("%s doesn't look like a secret, use y to copy it.", key)
+		return m, nil
+	}
+
+	reference := referenceFor(m.options.ReferenceTemplate, key)
+	if err := clipboard.Write(m.options.Clipboard, reference); err != nil {
+		m.statusMessage = fmt.Sprintf("Error copying: %v", err)
+		return m, nil
+	}
+	cmd := m.setStatus(fmt.Sprintf("Copied reference %s", reference))
+	return m, cmd
+}
+
+// copyFilePath copies the absolute path of the open file to the clipboard.
+func (m Model) copyFilePath() (Model, tea.Cmd) {
+	path, err := filepath.Abs(m.filePath)
+	if err != nil {
+		m.statusMessage = fmt.Sprintf("Error: resolving file path: %v", err)
+		return m, nil
+	}
+	if err := clipboard.Write(m.options.Clipboard, path); err != nil {
+		m.statusMessage = fmt.Sprintf("Error copying: %v", err)
+		return m, nil
+	}
+	cmd := m.setStatus(fmt.Sprintf("Copied %s", path))
+	return m, cmd
+}
+
+// referenceFor expands a reference template for key, replacing {key} with the variable name.
+func referenceFor(template, key string) string {
+	if template == "" {
+		template = config.DefaultReferenceTemplate
+	}
+	return strings.ReplaceAll(template, "{key}", key)
+}
+
 // --- Helper functions for Update --- (Will be expanded)
 
 // getCurrentListItems is a helper to get the dynamically generated list.
@@ -220,6 +863,41 @@ func (m Model) moveDown() Model {
 	return m
 }
 
+// setStatus shows a transient status message and returns the command clearing it
+// once the status timeout has elapsed. With a zero timeout, the message stays
+// until the next key press.
+func (m *Model) setStatus(text string) tea.Cmd {
+	m.statusMessage = text
+	if m.options.StatusTimeout <= 0 {
+		return nil
+	}
+	return tea.Tick(m.options.StatusTimeout, func(t time.Time) tea.Msg {
+		return clearStatusMsg{originalMsg: text}
+	})
+}
+
+// markModified flags the buffer as having unsaved changes.
+// When auto-save is enabled, it returns a command scheduling a debounced save.
+func (m *Model) markModified() tea.Cmd {
+	m.modified = true
+	m.revision++
+	if m.options.AutoSave <= 0 {
+		return nil
+	}
+	m.autosaveGen++
+	gen := m.autosaveGen
+	return tea.Tick(m.options.AutoSave, func(t time.Time) tea.Msg {
+		return autosaveMsg{gen: gen}
+	})
+}
+
+// clampCursor keeps the cursor on the list after rows were removed.
+func (m *Model) clampCursor() {
+	if items := m.getCurrentListItems(); m.cursor >= len(items) {
+		m.cursor = max(0, len(items)-1)
+	}
+}
+
 // ensureCursorVisible adjusts the viewport's YOffset to keep the cursor visible.
 func (m *Model) ensureCursorVisible() {
 	listItems := m.getCurrentListItems()
@@ -236,7 +914,7 @@ func (m *Model) ensureCursorVisible() {
 		return
 	}
 
-	scrollOff := 2
+	scrollOff := max(0, min(m.options.ContextLines, (m.viewport.Height-1)/2))
 	minVisible := m.viewport.YOffset
 	maxVisible := m.viewport.YOffset + m.viewport.Height - 1
 
@@ -246,11 +924,35 @@ func (m *Model) ensureCursorVisible() {
 		m.viewport.SetYOffset(min(listLen-m.viewport.Height, m.cursor-m.viewport.Height+1+scrollOff))
 	}
 
-	if m.cursor >= 0 && m.cursor < listLen {
+	if m.options.GroupContext {
+		// Reveal the rest of the focused group and the next header, if they fit with the cursor
+		end := groupContextEnd(listItems, m.cursor)
+		if end-m.cursor < m.viewport.Height && end > m.viewport.YOffset+m.viewport.Height-1 {
+			m.viewport.SetYOffset(end - m.viewport.Height + 1)
+		}
+	}
+
+	// File headers belong to no group: keep the last focused one
+	if m.cursor >= 0 && m.cursor < listLen && !listItems[m.cursor].isFileHeader {
 		m.focusIndex = listItems[m.cursor].groupIndex
 	}
 }
 
+// groupContextEnd returns the index of the last row to keep visible when the
+// cursor is on a group header: the row of the next group header, or the group's
+// last occurrence at the end of the list. Elsewhere, it returns cursor.
+func groupContextEnd(items []ListItem, cursor int) int {
+	if cursor < 0 || cursor >= len(items) || !items[cursor].isGroupHeader {
+		return cursor
+	}
+	for i := cursor + 1; i < len(items); i++ {
+		if items[i].isGroupHeader {
+			return i
+		}
+	}
+	return len(items) - 1
+}
+
 // toggleSelection handles the spacebar press to toggle group activity or select a value.
 func (m Model) toggleSelection() (Model, bool) {
 	listItems := m.getCurrentListItems()
@@ -296,6 +998,23 @@ func (m Model) toggleSelection() (Model, bool) {
 	return m, false // No change
 }
 
+// resizeViewport fits the list between the header and the footer, whose
+// heights vary, e.g. with the invalid lines listed under the header.
+func (m *Model) resizeViewport() {
+	if m.width == 0 {
+		return // Not sized yet
+	}
+	headerHeight := lipgloss.Height(m.renderHeader())
+	footerHeight := lipgloss.Height(m.renderFooter())
+	if m.viewport.Width == 0 || m.viewport.Height == 0 {
+		m.viewport = viewport.New(m.width, m.height-headerHeight-footerHeight)
+		m.viewport.YPosition = headerHeight
+	} else {
+		m.viewport.Width = m.width
+		m.viewport.Height = m.height - headerHeight - footerHeight
+	}
+}
+
 // updateViewportContent prepares the content string for the viewport.
 func (m *Model) updateViewportContent() {
 	// Viewport readiness is handled by initialization check
@@ -306,6 +1025,14 @@ func (m *Model) updateViewportContent() {
 	m.viewport.SetContent(listContent)
 }
 
+// acceptsCommands reports whether key presses are list commands, rather than
+// answers to a prompt or input for a dialog.
+func (m *Model) acceptsCommands() bool {
+	return !m.showQuitPrompt && !m.showReloadPrompt && !m.showOrphanPrompt && m.pendingDelete == nil &&
+		m.inputKind == inputNone && !m.showMerge && !m.showPalette && m.occurrencesKey == "" &&
+		m.pendingBookmark == bookmarkNone
+}
+
 // handleQuitPrompt handles key presses when the quit confirmation is shown.
 func (m Model) handleQuitPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
@@ -315,6 +1042,7 @@ func (m Model) handleQuitPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, m.saveCmd()
 	case "n", "N":
 		m.quitting = true
+		m.quitUnsaved = true
 		if m.watcherCancel != nil {
 			m.watcherCancel()
 		}
@@ -329,6 +1057,25 @@ func (m Model) handleQuitPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// handleOrphanPrompt handles key presses when asked whether to save despite orphaned lines.
+func (m Model) handleOrphanPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y":
+		m.showOrphanPrompt = false
+		m.statusMessage = "Saving..."
+		m.saveOrphans = true
+		cmd := m.saveCmd()
+		m.saveOrphans = false
+		return m, cmd
+	case "n", "N", "esc":
+		m.showOrphanPrompt = false
+		m.quittingAfterSave = false
+		m.statusMessage = "Save aborted."
+		return m, nil
+	}
+	return m, nil
+}
+
 // handleReloadPrompt handles key presses when the reload confirmation is shown.
 func (m Model) handleReloadPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch strings.ToLower(msg.String()) { // Case-insensitive
@@ -355,6 +1102,11 @@ func (m Model) handleReloadPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			cmd = m.watcher.WatchFileCmd()
 		}
 		return m, cmd
+	case "m": // Choose per variable between the disk and the buffer
+		m.showReloadPrompt = false
+		m.pendingReloadAction = nil
+		m.statusMessage = "Loading changes from disk..."
+		return m, m.loadMergeCmd()
 	case "esc": // Same as keep
 		m.showReloadPrompt = false
 		m.pendingReloadAction = nil
@@ -368,16 +1120,74 @@ func (m Model) handleReloadPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil // Ignore other keys
 }
 
-// reloadFileCmd creates a command to re-parse the file and update the model.
-func (m Model) reloadFileCmd() tea.Cmd {
+// retarget makes the model manage a new path, moving the lines of the current file to it.
+func (m Model) retarget(path string) Model {
+	for _, line := range m.parsedData.Lines {
+		if line.SourceFile == m.filePath || line.SourceFile == "" {
+			line.SourceFile = path
+		}
+	}
+	m.filePath = path
+	return m
+}
+
+// restartWatcher stops the current file watcher and starts a new one on the source files.
+func (m *Model) restartWatcher() tea.Cmd {
+	if m.watcher == nil {
+		return nil
+	}
+	if m.watcherCancel != nil {
+		m.watcherCancel()
+	}
+	w, err := watcher.New(m.options.Watch)
+	if err != nil {
+		m.watcher = nil
+		m.statusMessage = fmt.Sprintf("Watcher Error: %v", err)
+		return nil
+	}
+	m.watcher = w
+	m.watcherCtx, m.watcherCancel = context.WithCancel(context.Background())
+	m.watcher.Start(m.watcherCtx, m.watchedPaths()...)
+	return m.watcher.WatchFileCmd()
+}
+
+// watchedPaths returns the source files of the buffer, watched for external changes.
+func (m *Model) watchedPaths() []string {
+	sources, _ := groupLinesBySource(m.filePath, m.parsedData)
+	return sources
+}
+
+// reloadFileCmd creates a command to re-parse the changed source file at path
+// and update the model. The lines of the other source files are kept as they
+// are in the buffer.
+func (m Model) reloadFileCmd(path string) tea.Cmd {
+	sources, linesBySource := groupLinesBySource(m.filePath, m.parsedData)
+	kept := make(map[string]string, len(sources))
+	for _, source := range sources {
+		if source != path {
+			kept[source] = parser.RenderLines(linesBySource[source], m.parsedData)
+		}
+	}
+	opts := parser.Options{CommentMarker: m.options.CommentMarker}
 	return func() tea.Msg {
-		pd, err := parser.ParseFile(m.filePath)
-		if err != nil {
-			return errMsg{fmt.Errorf("failed to reload file: %w", err)}
+		parts := make([]*parser.ParsedData, 0, len(sources))
+		for _, source := range sources {
+			var part *parser.ParsedData
+			var err error
+			if content, ok := kept[source]; ok {
+				part, err = parser.ParseWithOptions(strings.NewReader(content), source, opts)
+			} else {
+				part, err = parser.ParseFileWithOptions(source, opts)
+			}
+			if err != nil {
+				return errMsg{fmt.Errorf("failed to reload file: %w", err)}
+			}
+			parts = append(parts, part)
+		}
+		if len(parts) == 1 {
+			return fileReloadedMsg{parsedData: parts[0]}
 		}
-		// Return new parsed data in a message (or update model directly?)
-		// Let's create a new message type for this.
-		return fileReloadedMsg{parsedData: pd}
+		return fileReloadedMsg{parsedData: parser.Concat(parts...)}
 	}
 }
 
@@ -385,12 +1195,109 @@ func (m Model) reloadFileCmd() tea.Cmd {
 
 // saveCmd is defined in actions.go
 
+// carrySelection copies the selection of the groups of prev into the groups
+// of next whose occurrences are unchanged on disk (same values and commented
+// state, in the same order).
+// It reports whether this makes next differ from what was read from disk.
+func carrySelection(prev, next *parser.ParsedData) bool {
+	changed := false
+	for _, key := range next.GroupOrder {
+		old, ok := prev.VariableGroups[key]
+		group := next.VariableGroups[key]
+		if !ok || !sameOccurrences(old, group) {
+			continue
+		}
+		if old.IsSelected != group.IsSelected || (old.IsSelected && old.SelectedLineIdx != group.SelectedLineIdx) {
+			changed = true
+		}
+		group.IsSelected = old.IsSelected
+		group.SelectedLineIdx = old.SelectedLineIdx
+	}
+	return changed
+}
+
+// sameOccurrences reports whether two groups hold the same lines, as read from
+// disk, in the same order.
+func sameOccurrences(a, b *parser.VariableGroup) bool {
+	if len(a.Lines) != len(b.Lines) {
+		return false
+	}
+	for i := range a.Lines {
+		if a.Lines[i].Value != b.Lines[i].Value || a.Lines[i].IsCommentedOut != b.Lines[i].IsCommentedOut {
+			return false
+		}
+	}
+	return true
+}
+
+// changedKeys returns the keys whose active value differs between the buffer
+// before and after a reload, each mapped to the given highlight expiry.
+func changedKeys(before, after *parser.ParsedData, expiry time.Time) map[string]time.Time {
+	changed := make(map[string]time.Time)
+	for _, c := range computeConflicts(before, after) {
+		changed[c.key] = expiry
+	}
+	return changed
+}
+
+// itemIndexForLine returns the index of the list item showing the first variable
+// line at or after the given 1-based file line number, or -1 if there is none.
+// Comment and blank lines resolve to the next variable line.
+func itemIndexForLine(items []ListItem, pd *parser.ParsedData, lineNumber int) int {
+	best := -1
+	bestLine := 0
+	for i, item := range items {
+		if item.valueIndex < 0 || item.groupIndex < 0 {
+			continue
+		}
+		line := pd.VariableGroups[pd.GroupOrder[item.groupIndex]].Lines[item.valueIndex]
+		if line.LineNumber >= lineNumber && (best == -1 || line.LineNumber < bestLine) {
+			best = i
+			bestLine = line.LineNumber
+		}
+	}
+	return best
+}
+
+// itemIndexOfLine returns the index of the list item showing line, or -1.
+func itemIndexOfLine(items []ListItem, pd *parser.ParsedData, line *parser.Line) int {
+	for i, item := range items {
+		if item.valueIndex < 0 || item.groupIndex < 0 {
+			continue
+		}
+		if pd.VariableGroups[pd.GroupOrder[item.groupIndex]].Lines[item.valueIndex] == line {
+			return i
+		}
+	}
+	return -1
+}
+
+// focusedLine returns the variable line under the cursor, or nil if the cursor is not on a value line.
+func (m *Model) focusedLine() *parser.Line {
+	listItems := m.getCurrentListItems()
+	if m.cursor < 0 || m.cursor >= len(listItems) {
+		return nil
+	}
+	item := listItems[m.cursor]
+	if item.isFileHeader || (item.isGroupHeader && !item.isCompact) {
+		return nil
+	}
+	group := m.parsedData.VariableGroups[m.parsedData.GroupOrder[item.groupIndex]]
+	return group.Lines[item.valueIndex]
+}
+
 func (m *Model) getSelectedLineContent() string {
 	listItems := m.getCurrentListItems()
+	if m.cursor < 0 || m.cursor >= len(listItems) {
+		return ""
+	}
 
 	selectedItem := listItems[m.cursor]
-	if selectedItem.isGroupHeader {
+	if selectedItem.isGroupHeader || selectedItem.isFileHeader {
 		return selectedItem.key
 	}
+	if m.options.CopyQuoted {
+		return parser.QuoteValue(selectedItem.value)
+	}
 	return selectedItem.value
 }
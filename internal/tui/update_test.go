@@ -0,0 +1,460 @@
+package tui
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/taha-yassine/sidem/internal/clipboard"
+	"github.com/taha-yassine/sidem/internal/config"
+	"github.com/taha-yassine/sidem/internal/parser"
+	"github.com/taha-yassine/sidem/internal/watcher"
+)
+
+// listKeys are the list commands that act on the focused row.
+var listKeys = []string{"y", "Y", "A", "C", "e", "d", "o", "O", "!", "r", "R", "p", "P", "x", "i", "u", "ctrl+r", " ", "enter"}
+
+func TestKeysOnEmptyList(t *testing.T) {
+	m := newTestModel(t, "", Options{})
+	for _, key := range listKeys {
+		press(m, key, "esc")
+	}
+}
+
+func TestKeysWithoutFilterMatches(t *testing.T) {
+	m := newTestModel(t, "A=1\nB=2\n", Options{})
+	m = press(m, "/", "zzz", "enter")
+	if m.filterMatches() != 0 {
+		t.Fatalf("filter %q matches %d groups", m.filter, m.filterMatches())
+	}
+	for _, key := range listKeys {
+		press(m, key, "esc")
+	}
+}
+
+func TestItemIndexForLine(t *testing.T) {
+	m := newTestModel(t, "# comment\nA=1\n\n# B=2\nB=3\n# trailing\n", Options{})
+	items := m.getCurrentListItems()
+
+	// Items: A, A=1 (line 2), B, # B=2 (line 4), B=3 (line 5)
+	tests := []struct {
+		line, want int
+	}{
+		{1, 1}, // Comment, resolves to the next variable line
+		{2, 1},
+		{3, 3}, // Blank line
+		{4, 3}, // Commented-out variable
+		{5, 4},
+		{6, -1}, // Nothing after it
+		{99, -1},
+	}
+	for _, tt := range tests {
+		if got := itemIndexForLine(items, m.parsedData, tt.line); got != tt.want {
+			t.Errorf("itemIndexForLine(%d) = %d, want %d", tt.line, got, tt.want)
+		}
+	}
+
+	m = press(m, "L", "3", "enter")
+	if m.cursor != 3 {
+		t.Errorf("going to line 3 moved the cursor to %d, want 3", m.cursor)
+	}
+}
+
+func TestSortOccurrencesKey(t *testing.T) {
+	m := newTestModel(t, "A=2 # b\n# A=1 # a\n", Options{})
+	m = press(m, "O")
+	if got := m.parsedData.VariableGroups["A"].Lines[0].Comment; got != "a" {
+		t.Fatalf("first occurrence has comment %q after sorting, want a", got)
+	}
+	if !m.modified || activeValue(t, m, "A") != "2" {
+		t.Errorf("modified: %v, active value %q, want modified with 2 still active", m.modified, activeValue(t, m, "A"))
+	}
+
+	m = press(m, "u")
+	if got := m.parsedData.VariableGroups["A"].Lines[0].Comment; got != "b" {
+		t.Errorf("first occurrence has comment %q after undoing, want b", got)
+	}
+}
+
+func TestStatusTimeout(t *testing.T) {
+	// Without a timeout, a message stays until the next key press
+	m := newTestModel(t, "A=1\n", Options{})
+	if cmd := m.setStatus("Saved."); cmd != nil {
+		t.Error("setStatus scheduled a clear without a timeout")
+	}
+	if m = press(m, "down"); m.statusMessage != "" {
+		t.Errorf("status is %q after a key press, want it cleared", m.statusMessage)
+	}
+
+	// With one, the returned tick clears it, unless another message replaced it meanwhile
+	m = newTestModel(t, "A=1\n", Options{StatusTimeout: time.Millisecond})
+	cmd := m.setStatus("Saved.")
+	if cmd == nil {
+		t.Fatal("setStatus scheduled no clear with a timeout")
+	}
+	msg := cmd()
+	m.statusMessage = "Copied."
+	updated, _ := m.Update(msg)
+	if m = updated.(Model); m.statusMessage != "Copied." {
+		t.Errorf("clearing the old message cleared %q", "Copied.")
+	}
+	m.setStatus("Saved.")
+	updated, _ = m.Update(msg)
+	if m = updated.(Model); m.statusMessage != "" {
+		t.Errorf("status is %q once the timeout elapsed, want it cleared", m.statusMessage)
+	}
+}
+
+func TestStatusTimeoutPreference(t *testing.T) {
+	timeout := config.Duration(5 * time.Second)
+	cfg := &config.Config{StatusTimeout: &timeout}
+
+	if got := (Options{StatusTimeout: 2 * time.Second}).WithConfig(cfg).StatusTimeout; got != 5*time.Second {
+		t.Errorf("status timeout is %s with the preference set, want 5s", got)
+	}
+	opts := Options{StatusTimeout: 0, FlagsSet: map[string]bool{"status-timeout": true}}
+	if got := opts.WithConfig(cfg).StatusTimeout; got != 0 {
+		t.Errorf("status timeout is %s with --status-timeout=0, want the flag to win", got)
+	}
+}
+
+func TestSaveIgnoresOwnWatcherEvent(t *testing.T) {
+	m := newTestModel(t, "PORT=5432\n# PORT=6543\n", Options{})
+	m = press(m, "down", "down", " ")
+	m, cmd := m.save()
+	updated, _ := m.Update(cmd())
+	m = updated.(Model)
+	// Edited again before the watcher reports the save
+	m = press(m, "k", " ")
+
+	updated, _ = m.Update(watcher.FileChangedMsg{Path: m.filePath})
+	m = updated.(Model)
+	if m.showReloadPrompt {
+		t.Fatal("the save's own write prompted to reload")
+	}
+	if got := activeValue(t, m, "PORT"); got != "5432" {
+		t.Errorf("PORT = %q, want the unsaved 5432", got)
+	}
+
+	// A change made by someone else still prompts
+	if err := os.WriteFile(m.filePath, []byte("PORT=1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	updated, _ = m.Update(watcher.FileChangedMsg{Path: m.filePath})
+	if !updated.(Model).showReloadPrompt {
+		t.Error("an external change didn't prompt to reload")
+	}
+}
+
+func TestReloadKeepsSelectionOfUntouchedGroups(t *testing.T) {
+	m := newTestModel(t, "A=1\n# A=2\nB=x\n# B=y\nC=on\n", Options{})
+	// Choices made in the TUI: A uses its second value, C is disabled, B is edited
+	m.parsedData.VariableGroups["A"].SelectedLineIdx = 1
+	m.parsedData.VariableGroups["C"].IsSelected = false
+	m.parsedData.VariableGroups["B"].SelectedLineIdx = 1
+	m.markModified()
+
+	// An external edit to B only
+	if err := os.WriteFile(m.filePath, []byte("A=1\n# A=2\nB=z\n# B=y\nC=on\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	updated, _ := m.Update(m.reloadFileCmd(m.filePath)())
+	m = updated.(Model)
+
+	if group := m.parsedData.VariableGroups["A"]; !group.IsSelected || group.SelectedLineIdx != 1 {
+		t.Errorf("A uses line %d (selected: %v) after the reload, want its second value kept", group.SelectedLineIdx, group.IsSelected)
+	}
+	if m.parsedData.VariableGroups["C"].IsSelected {
+		t.Error("C was enabled again by the reload")
+	}
+	if got := activeValue(t, m, "B"); got != "z" {
+		t.Errorf("B is %q after the reload, want the value from disk", got)
+	}
+	if !m.modified {
+		t.Error("buffer not marked modified although it differs from disk")
+	}
+}
+
+func TestToggleRequired(t *testing.T) {
+	m := newTestModel(t, "DB_HOST=localhost\nPORT=5432\n", Options{})
+	m = press(m, "!")
+	if !m.parsedData.RequiredKeys()["DB_HOST"] || !m.modified {
+		t.Fatal("DB_HOST not marked as required")
+	}
+	if !hasLine(m.View(), "> [✓] DB_HOST"+iconRequired) {
+		t.Errorf("no required marker shown:\n%s", m.View())
+	}
+
+	m = press(m, "!")
+	if m.parsedData.RequiredKeys()["DB_HOST"] || strings.Contains(m.View(), iconRequired) {
+		t.Error("DB_HOST still required after toggling again")
+	}
+}
+
+func TestOccurrencesText(t *testing.T) {
+	m := newTestModel(t, "# API=http://dev # dev\nOTHER=1\nexport API=\"https://prod\" # prod\n# API=http://local\n", Options{})
+	const want = "# API=http://dev # dev\nexport API=\"https://prod\" # prod\n# API=http://local\n"
+	if got := m.occurrencesText("API"); got != want {
+		t.Errorf("copied %q, want %q", got, want)
+	}
+
+	// The copy follows the selection made in the buffer
+	m.parsedData.VariableGroups["API"].SelectedLineIdx = 2
+	const wantSwitched = "# API=http://dev # dev\n# export API=\"https://prod\" # prod\nAPI=http://local\n"
+	if got := m.occurrencesText("API"); got != wantSwitched {
+		t.Errorf("copied %q after switching, want %q", got, wantSwitched)
+	}
+}
+
+func TestCopyQuoted(t *testing.T) {
+	const content = "GREETING=hello world\nPLAIN=abc\nHASH=\"a#b\"\nQUOTE='say \"hi\"'\nEMPTY=\n"
+	tests := []struct {
+		key        string
+		raw, quote string
+	}{
+		{"GREETING", "hello world", `"hello world"`},
+		{"PLAIN", "abc", "abc"},
+		{"HASH", "a#b", `"a#b"`},
+		{"QUOTE", `say "hi"`, `'say "hi"'`},
+		{"EMPTY", "", ""},
+	}
+	plain := newTestModel(t, content, Options{})
+	quoted := newTestModel(t, content, Options{CopyQuoted: true})
+	for i, tt := range tests {
+		// Each group is a header followed by its single value
+		plain.cursor, quoted.cursor = 2*i+1, 2*i+1
+		if got := plain.getSelectedLineContent(); got != tt.raw {
+			t.Errorf("%s copied as %q, want %q", tt.key, got, tt.raw)
+		}
+		if got := quoted.getSelectedLineContent(); got != tt.quote {
+			t.Errorf("%s copied quoted as %q, want %q", tt.key, got, tt.quote)
+		}
+	}
+}
+
+func TestToggleTheme(t *testing.T) {
+	m := newTestModel(t, "A=1\n", Options{})
+	dracula, nature := DefaultStyles(), NatureStyles()
+	if dracula.KeyStyle.GetForeground() == nature.KeyStyle.GetForeground() {
+		t.Fatal("test assumes the themes color keys differently")
+	}
+	if m.styles.KeyStyle.GetForeground() != dracula.KeyStyle.GetForeground() {
+		t.Fatal("default theme not active at start")
+	}
+
+	m = press(m, "t")
+	if !m.natureTheme || m.styles.KeyStyle.GetForeground() != nature.KeyStyle.GetForeground() ||
+		m.styles.FocusedLine.GetBackground() != nature.FocusedLine.GetBackground() {
+		t.Error("t didn't switch to the nature styles")
+	}
+	m = press(m, "t")
+	if m.natureTheme || m.styles.KeyStyle.GetForeground() != dracula.KeyStyle.GetForeground() {
+		t.Error("t again didn't switch back to the default styles")
+	}
+}
+
+func TestEnterTogglesLikeSpace(t *testing.T) {
+	const content = "A=1\n# A=2\n# B=x\nC=y\n"
+	state := func(m Model) string {
+		var b strings.Builder
+		for _, key := range m.parsedData.GroupOrder {
+			group := m.parsedData.VariableGroups[key]
+			fmt.Fprintf(&b, "%s:%v:%d ", key, group.IsSelected, group.SelectedLineIdx)
+		}
+		return b.String()
+	}
+	// Rows: A, A=1, # A=2, B, # B=x, C, C=y
+	for row := range 7 {
+		moves := slices.Repeat([]string{"down"}, row)
+		space := press(newTestModel(t, content, Options{}), append(moves, " ")...)
+		enter := press(newTestModel(t, content, Options{}), append(moves, "enter")...)
+		if state(enter) != state(space) {
+			t.Errorf("row %d: Enter left %s, Space %s", row, state(enter), state(space))
+		}
+		if enter.modified != space.modified {
+			t.Errorf("row %d: modified %v after Enter, %v after Space", row, enter.modified, space.modified)
+		}
+		if row == 2 && !enter.modified {
+			t.Error("Enter on an inactive value didn't select it")
+		}
+	}
+
+	// Editing a value, Enter commits it without toggling
+	m := newTestModel(t, content, Options{})
+	m = press(m, "down", "e", "ctrl+u", "9", "enter")
+	if got := activeValue(t, m, "A"); got != "9" {
+		t.Errorf("A = %q after editing, want 9", got)
+	}
+	if group := m.parsedData.VariableGroups["A"]; !group.IsSelected || group.SelectedLineIdx != 0 {
+		t.Error("Enter committing an edit toggled the selection")
+	}
+}
+
+func TestGroupContextOffset(t *testing.T) {
+	var content strings.Builder
+	for i := range 30 {
+		fmt.Fprintf(&content, "S%02d=1\n", i)
+	}
+	for i := range 10 {
+		fmt.Fprintf(&content, "# BIG=%d\n", i)
+	}
+	content.WriteString("Z=1\n")
+	// Rows: 30 two-row groups, BIG's header at 60 and values 61-70, Z's header at 71
+
+	for _, tt := range []struct {
+		context bool
+		last    int // Row expected on the last line of the viewport
+	}{
+		{false, 60}, // Just the cursor
+		{true, 71},  // Through the next header
+	} {
+		m := newTestModel(t, content.String(), Options{GroupContext: tt.context})
+		if m.viewport.Height < 13 {
+			t.Fatalf("viewport of %d rows too small for the test", m.viewport.Height)
+		}
+		m = press(m, slices.Repeat([]string{"down"}, 60)...)
+		if got := m.viewport.YOffset + m.viewport.Height - 1; got != tt.last {
+			t.Errorf("group context %v: last visible row %d, want %d", tt.context, got, tt.last)
+		}
+	}
+}
+
+func TestGroupContextEnd(t *testing.T) {
+	items := []ListItem{
+		{isGroupHeader: true}, {}, {}, // A and two values
+		{isGroupHeader: true, isCompact: true}, // Compact B
+		{isGroupHeader: true}, {},              // C, last
+	}
+	for cursor, want := range []int{3, 1, 2, 4, 5, 5} {
+		if got := groupContextEnd(items, cursor); got != want {
+			t.Errorf("groupContextEnd(%d) = %d, want %d", cursor, got, want)
+		}
+	}
+}
+
+func TestChangedKeys(t *testing.T) {
+	before := parseContent(t, "SAME=1\nEDITED=old\n# ENABLED=on\nDISABLED=x\nREMOVED=r\nSWITCHED=a\n# SWITCHED=b\n")
+	after := parseContent(t, "SAME=1\nEDITED=new\nENABLED=on\n# DISABLED=x\n# SWITCHED=a\nSWITCHED=b\nADDED=n\n")
+	expiry := time.Now().Add(time.Minute)
+
+	got := changedKeys(before, after, expiry)
+	want := []string{"ADDED", "DISABLED", "EDITED", "ENABLED", "REMOVED", "SWITCHED"}
+	if keys := slices.Sorted(maps.Keys(got)); !slices.Equal(keys, want) {
+		t.Errorf("changed keys %v, want %v", keys, want)
+	}
+	for key, until := range got {
+		if !until.Equal(expiry) {
+			t.Errorf("%s highlighted until %v, want %v", key, until, expiry)
+		}
+	}
+}
+
+func TestReloadHighlightsChangedKeys(t *testing.T) {
+	m := newTestModel(t, "A=1\nB=2\n", Options{})
+	if err := os.WriteFile(m.filePath, []byte("A=1\nB=3\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	updated, _ := m.Update(m.reloadFileCmd(m.filePath)())
+	m = updated.(Model)
+	if _, ok := m.changedOnDisk["B"]; !ok || len(m.changedOnDisk) != 1 {
+		t.Errorf("highlighted %v after B changed on disk", m.changedOnDisk)
+	}
+	if !time.Now().Before(m.changedOnDisk["B"]) {
+		t.Error("B's highlight already expired")
+	}
+}
+
+func TestFlashLifecycle(t *testing.T) {
+	m := newTestModel(t, "A=1\n# A=2\nB=x\n# B=y\n", Options{})
+	before := m
+	m = press(m, "down", "down", " ")
+	items := m.getCurrentListItems()
+	second := m.parsedData.VariableGroups["A"].Lines[1]
+
+	if len(m.flashed) != 1 || !m.isFlashed(items[2]) || m.isFlashed(items[1]) {
+		t.Fatalf("flashed lines are %v, want A's new value only", m.flashed)
+	}
+	if len(before.flashed) != 0 {
+		t.Error("flashing changed a previous model")
+	}
+
+	// Once expired, the line settles and the next flash drops it
+	m.flashed[second] = time.Now().Add(-time.Millisecond)
+	if m.isFlashed(items[2]) {
+		t.Error("line still flashed after its expiry")
+	}
+	m = press(m, "down", "down", "down", " ")
+	if _, ok := m.flashed[second]; ok || len(m.flashed) != 1 {
+		t.Errorf("flashed lines are %v, want B's new value only", m.flashed)
+	}
+
+	// Disabled, toggling flashes nothing
+	m = newTestModel(t, "A=1\n# A=2\n", Options{NoFlash: true})
+	m = press(m, "down", "down", " ")
+	if len(m.flashed) != 0 {
+		t.Errorf("flashed %v with flashing disabled", m.flashed)
+	}
+	if cmd := m.flashActive("A"); cmd != nil {
+		t.Error("flashActive scheduled a clear with flashing disabled")
+	}
+}
+
+func TestReferenceFor(t *testing.T) {
+	tests := []struct {
+		template, key, want string
+	}{
+		{"", "API_TOKEN", "${API_TOKEN}"},
+		{"{{ .Env.{key} }}", "DB_PASSWORD", "{{ .Env.DB_PASSWORD }}"},
+		{"op://vault/{key}/{key}", "SECRET", "op://vault/SECRET/SECRET"},
+		{"vault:secret", "SECRET", "vault:secret"},
+	}
+	for _, tt := range tests {
+		if got := referenceFor(tt.template, tt.key); got != tt.want {
+			t.Errorf("referenceFor(%q, %q) = %q, want %q", tt.template, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestCopyReferenceSkipsNonSecrets(t *testing.T) {
+	m := newTestModel(t, "PORT=5432\n", Options{})
+	m.cursor = 1
+	m = press(m, "R")
+	if want := "PORT doesn't look like a secret, use y to copy it."; m.statusMessage != want {
+		t.Errorf("status %q, want %q", m.statusMessage, want)
+	}
+}
+
+func TestEditValueWithEquals(t *testing.T) {
+	m := newTestModel(t, "DSN=postgres://db/app?sslmode=require\n", Options{})
+	m.cursor = 1
+	m = press(m, "e")
+	if got := m.input.Value(); got != "postgres://db/app?sslmode=require" {
+		t.Fatalf("editor opened with %q, want the whole value", got)
+	}
+	m = press(m, "ctrl+u", "postgres://db/app?sslmode=disable&pool=5", "enter")
+
+	rendered := parser.RenderLines(m.parsedData.Lines, m.parsedData)
+	if want := "DSN=\"postgres://db/app?sslmode=disable&pool=5\"\n"; rendered != want {
+		t.Errorf("rendered %q, want %q", rendered, want)
+	}
+	if got := parseContent(t, rendered).VariableGroups["DSN"].ActiveLine().Value; got != "postgres://db/app?sslmode=disable&pool=5" {
+		t.Errorf("DSN reads back as %q", got)
+	}
+}
+
+func TestCopyFilePathIsAbsolute(t *testing.T) {
+	m := newTestModel(t, "A=1\n", Options{Clipboard: clipboard.BackendOSC52})
+	dir := filepath.Dir(m.filePath)
+	t.Chdir(dir)
+	m.filePath = ".env" // As opened from the command line
+
+	m = press(m, "C")
+	if want := "Copied " + filepath.Join(dir, ".env"); m.statusMessage != want {
+		t.Errorf("status %q, want %q", m.statusMessage, want)
+	}
+}
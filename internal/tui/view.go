@@ -2,9 +2,19 @@ package tui
 
 import (
 	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/taha-yassine/sidem/internal/config"
 	"github.com/taha-yassine/sidem/internal/parser"
+	"github.com/taha-yassine/sidem/internal/secrets"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/x/ansi"
@@ -27,8 +37,24 @@ func (m Model) View() string {
 	header := m.renderHeader()
 	footer := m.renderFooter()
 
+	body := m.viewport.View()
+	if m.detailVisible() {
+		list := m.viewport
+		list.Width = m.listWidth()
+		body = lipgloss.JoinHorizontal(lipgloss.Top, list.View(), m.renderDetail(m.viewport.Height))
+	}
+	if m.showPalette {
+		body = m.renderPalette(m.viewport.Height)
+	} else if m.showMerge {
+		body = m.renderMerge(m.viewport.Height)
+	} else if m.occurrencesKey != "" {
+		body = m.renderOccurrences(m.viewport.Height)
+	} else if m.showSharedValues {
+		body = m.renderSharedValues(m.viewport.Height)
+	}
+
 	// Combine header, viewport, and footer
-	return fmt.Sprintf("%s\n%s\n%s", header, m.viewport.View(), footer)
+	return fmt.Sprintf("%s\n%s\n%s", header, body, footer)
 }
 
 // renderHeader renders the top header bar.
@@ -41,22 +67,82 @@ func (m *Model) renderHeader() string { // Pointer receiver for consistency
 		modifiedStatus = m.styles.ModifiedStatus.Render(" [MODIFIED]")
 	}
 
-	fileInfo := fmt.Sprintf("%s%s", filePath, modifiedStatus)
+	autosaveStatus := ""
+	if m.options.AutoSave > 0 {
+		autosaveStatus = m.styles.StatusMessage.Render(" [AUTOSAVE]")
+	}
+
+	if m.options.ReadOnly {
+		autosaveStatus = m.styles.ErrorMessage.Render(" [READ-ONLY]")
+	}
+
+	fileInfo := fmt.Sprintf("%s%s%s", filePath, modifiedStatus, autosaveStatus)
 	titleWidth := lipgloss.Width(title)
 	fileInfoWidth := lipgloss.Width(fileInfo)
 
 	spaces := max(0, m.width-titleWidth-fileInfoWidth-m.styles.HeaderTitle.GetHorizontalPadding()-m.styles.HeaderFileInfo.GetHorizontalPadding())
 
-	header := fmt.Sprintf("%s%s%s", m.styles.HeaderTitle.Render(title), strings.Repeat(" ", spaces), m.styles.HeaderFileInfo.Render(fileInfo))
+	titleStyle := m.styles.HeaderTitle
+	if accent, ok := headerAccent(m.filePath, m.options.HeaderAccents); ok {
+		// Loud title so the environment being edited can't be mistaken
+		titleStyle = titleStyle.Background(accent).Foreground(lipgloss.Color("#282a36"))
+	}
+
+	header := fmt.Sprintf("%s%s%s", titleStyle.Render(title), strings.Repeat(" ", spaces), m.styles.HeaderFileInfo.Render(fileInfo))
+
+	rendered := m.styles.Header.Width(m.width).Render(header)
+	if invalid := m.renderInvalidLines(); invalid != "" {
+		rendered += "\n" + invalid
+	}
+	return rendered
+}
+
+// renderInvalidLines renders the row listing the lines kept as comments
+// because they are neither comments nor valid assignments, e.g. "1FOO=bar",
+// or "" if there are none.
+func (m *Model) renderInvalidLines() string {
+	if m.parsedData == nil {
+		return ""
+	}
+	invalid := m.parsedData.InvalidLines()
+	if len(invalid) == 0 {
+		return ""
+	}
+	parts := make([]string, len(invalid))
+	for i, line := range invalid {
+		location := strconv.Itoa(line.LineNumber)
+		if line.SourceFile != m.filePath {
+			location = filepath.Base(line.SourceFile) + ":" + location
+		}
+		parts[i] = location + ": " + strings.TrimSpace(line.OriginalContent)
+	}
+	text := fmt.Sprintf("%s%d invalid line(s) kept as comments: %s", iconInvalid, len(invalid), strings.Join(parts, " · "))
+	return m.styles.ErrorMessage.Render(ansi.Truncate(text, m.width, "…"))
+}
 
-	return m.styles.Header.Width(m.width).Render(header)
+// headerAccent returns the color tinting the header of the file at path, from
+// the first rule whose Match is contained in the file name, case-insensitively.
+// Nil rules fall back to config.DefaultHeaderAccents.
+func headerAccent(path string, rules []config.HeaderAccent) (lipgloss.Color, bool) {
+	if rules == nil {
+		rules = config.DefaultHeaderAccents
+	}
+	name := strings.ToLower(filepath.Base(path))
+	for _, rule := range rules {
+		if rule.Match != "" && strings.Contains(name, strings.ToLower(rule.Match)) {
+			return lipgloss.Color(rule.Color), true
+		}
+	}
+	return "", false
 }
 
 // renderFooter renders the bottom help/status bar.
 func (m *Model) renderFooter() string { // Pointer receiver for consistency
-	help := "↑/↓/j/k: Navigate | Space: Toggle/Select | y: Copy | Ctrl+S: Save | q/Ctrl+C: Quit"
+	help := m.footerHelp()
 	quitPrompt := "Save changes before quitting? ([Y]es/[N]o/[C]ancel)"
-	reloadPrompt := "File changed externally. [R]eload (lose TUI changes) / [K]eep TUI changes?"
+	reloadPrompt := "File changed externally. [R]eload (keep choices for untouched variables) / [K]eep TUI changes / [M]erge?"
+	occurrencesHelp := "↑/↓: Navigate | Space/Enter: Make active | e: Edit value | d: Delete | Esc/o: Back"
+	mergeHelp := "↑/↓: Navigate | ←/→/Space: Choose disk or buffer | Enter: Apply | Esc: Keep buffer"
 
 	var content string
 	var style lipgloss.Style = m.styles.Footer // Default style
@@ -65,6 +151,18 @@ func (m *Model) renderFooter() string { // Pointer receiver for consistency
 		content = m.styles.PromptStyle.Render(quitPrompt)
 	} else if m.showReloadPrompt {
 		content = m.styles.PromptStyle.Render(reloadPrompt)
+	} else if m.showOrphanPrompt {
+		content = m.styles.ErrorMessage.Render(fmt.Sprintf("Warning: %d line(s) lost their variable and would be written as error comments. Save anyway? ([Y]es/[N]o)", m.orphanCount))
+	} else if m.pendingDelete != nil {
+		content = m.styles.ErrorMessage.Render(m.deletePrompt())
+	} else if m.inputKind == inputValue {
+		content = m.styles.PromptStyle.Render(m.inputPrompt) // The input itself is drawn in the list
+	} else if m.inputKind != inputNone {
+		content = m.styles.PromptStyle.Render(m.inputPrompt+" ") + m.input.View()
+	} else if m.showMerge {
+		content = m.styles.PromptStyle.Render(mergeHelp)
+	} else if m.occurrencesKey != "" && m.statusMessage == "" {
+		content = m.styles.PromptStyle.Render(occurrencesHelp)
 	} else if m.statusMessage != "" {
 		// Display status message instead of help when present
 		if strings.HasPrefix(m.statusMessage, "Error:") {
@@ -72,21 +170,142 @@ func (m *Model) renderFooter() string { // Pointer receiver for consistency
 		} else {
 			content = m.styles.StatusMessage.Render(m.statusMessage)
 		}
+	} else if note := m.longValueNote(); note != "" {
+		content = m.styles.ModifiedStatus.Render(note)
 	} else {
 		content = help
 	}
 
 	// TODO: Add hot reload prompt display
 
+	// Scroll position indicator on the right, if there's room for it
+	position := m.styles.ScrollIndicator.Render(m.scrollPosition())
+	if m.filter != "" {
+		position = m.styles.ModifiedStatus.Render(fmt.Sprintf("/%s: %d matches ", m.filter, m.filterMatches())) + position
+	}
+	if m.sortMode != sortFileOrder {
+		position = m.styles.ModifiedStatus.Render(fmt.Sprintf("[%s] ", m.sortMode)) + position
+	}
+	if (m.options.MaskSecrets || len(m.maskedKeys) > 0) && !m.peeking() {
+		position = m.styles.ModifiedStatus.Render("[MASKED] ") + position
+	}
+	if n := len(m.parsedData.Conflicts); n > 0 {
+		position = m.styles.ErrorMessage.Render(fmt.Sprintf("%d duplicate(s) ", n)) + position
+	}
+	available := m.width - lipgloss.Width(position) - 1
+	if available > 0 {
+		content = lipgloss.JoinHorizontal(lipgloss.Top,
+			lipgloss.NewStyle().Width(available).Render(content),
+			" ",
+			position,
+		)
+	}
+
 	return style.Width(m.width).Render(content)
 }
 
+// footerHelp returns the key bindings relevant to what the cursor is on: a
+// group header, a value line or a file header. Every other action stays
+// reachable from the command palette.
+func (m *Model) footerHelp() string {
+	help := []string{"↑/↓/j/k: Navigate"}
+
+	listItems := m.getCurrentListItems()
+	switch {
+	case m.cursor < 0 || m.cursor >= len(listItems):
+		help = append(help, "a: Add variable", "c: Compact", "F: Group by file", "s: Sort", "t: Theme")
+	case listItems[m.cursor].isFileHeader:
+		help = append(help, "y: Copy file name", "C: Copy file path", "F: Ungroup")
+	default:
+		key := m.parsedData.GroupOrder[listItems[m.cursor].groupIndex]
+		if m.focusedLine() != nil {
+			help = append(help, "Space/Enter: Select", "e: Edit", "y: Copy value", "Y: Copy KEY=value", "r: Resolve", "i: Snippet")
+		} else {
+			help = append(help, "Space/Enter: Toggle", "y: Copy key", "Y: Copy KEY=value", "A: Copy all occurrences", "O: Sort by comment")
+		}
+		help = append(help, "M: Mask secrets")
+		if m.options.MaskSecrets {
+			help = append(help, "p: Peek")
+		}
+		if _, ok := m.overrides[key]; ok {
+			help = append(help, "P: Promote override")
+		}
+		if m.isConflicted(key) {
+			help = append(help, "x: Cycle duplicates")
+		}
+		help = append(help, "a: Add", "d: Delete", "o: Occurrences", "!: Required", "m/': Set/jump to bookmark")
+	}
+
+	if m.filter != "" {
+		help = append(help, "Esc: Clear filter")
+	} else {
+		help = append(help, "/: Filter")
+	}
+	help = append(help, "Ctrl+S: Save", "Ctrl+K/:: All commands", "q/Ctrl+C: Quit")
+	return strings.Join(help, " | ")
+}
+
+// isLongValue reports whether value exceeds the --warn-value-length threshold.
+func (m *Model) isLongValue(value string) bool {
+	return m.options.WarnValueLength > 0 && utf8.RuneCountInString(value) > m.options.WarnValueLength
+}
+
+// longValueNote returns the footer warning shown while the cursor is on a value
+// exceeding the --warn-value-length threshold, or "" otherwise.
+func (m *Model) longValueNote() string {
+	line := m.focusedLine()
+	if line == nil || !m.isLongValue(line.Value) {
+		return ""
+	}
+	return fmt.Sprintf("Warning: this value of %s is %d characters long (over %d), was it pasted by mistake?",
+		line.Key, utf8.RuneCountInString(line.Value), m.options.WarnValueLength)
+}
+
+// scrollPosition returns the "row X/Y (NN%)" indicator for the footer.
+func (m *Model) scrollPosition() string {
+	total := len(m.getCurrentListItems())
+	row := 0
+	if total > 0 {
+		row = m.cursor + 1
+	}
+	return fmt.Sprintf("row %d/%d (%d%%)", row, total, scrollPercent(m.viewport.YOffset, m.viewport.Height, total))
+}
+
+// scrollPercent computes how far the viewport is scrolled through a list of total rows,
+// from 0 at the top to 100 at the bottom. A list that fits entirely is at 100%.
+func scrollPercent(yOffset, height, total int) int {
+	scrollable := total - height
+	if scrollable <= 0 {
+		return 100
+	}
+	return min(100, max(0, yOffset*100/scrollable))
+}
+
 // renderList generates the string content for the scrollable list view.
 func (m *Model) renderList() string {
 	var builder strings.Builder
 	listItems := m.buildListItems()
 
+	required := m.parsedData.RequiredKeys()
+
+	valueColumn := 0
+	if m.alignValues {
+		valueColumn = alignColumn(listItems, m.listWidth())
+	}
+
 	for i, item := range listItems {
+		if item.isFileHeader {
+			fileHeader := m.styles.FileHeader.Render(item.key)
+			if i == m.cursor {
+				fileHeader = m.styles.FocusedLine.Render(iconPointer) + fileHeader
+			} else {
+				fileHeader = "  " + fileHeader
+			}
+			builder.WriteString(ansi.Truncate(fileHeader, m.listWidth(), "…"))
+			builder.WriteString("\n")
+			continue
+		}
+
 		pointer := "  "
 		var prefixIcon string
 		var prefixIconStyle, textStyle lipgloss.Style
@@ -104,6 +323,10 @@ func (m *Model) renderList() string {
 				prefixIcon = iconRadioOn
 			}
 			prefixIcon = fmt.Sprintf("	%s ", prefixIcon)
+			if m.hideRadio && len(m.parsedData.VariableGroups[m.parsedData.GroupOrder[item.groupIndex]].Lines) == 1 {
+				// Nothing to choose, align the value under its key
+				prefixIcon = "    "
+			}
 		}
 
 		if i == m.cursor {
@@ -132,23 +355,92 @@ func (m *Model) renderList() string {
 		lineContent.WriteString(pointer)
 
 		lineContent.WriteString(prefixIconStyle.Render(prefixIcon))
+		if (!item.isGroupHeader || item.isCompact) && m.isLongValue(item.value) {
+			lineContent.WriteString(m.styles.ModifiedStatus.Render(iconLongValue))
+		}
 
 		// Render key or value
 		var content string
 		if item.isGroupHeader {
 			content = item.key
+			if i != m.cursor && time.Now().Before(m.changedOnDisk[item.key]) {
+				textStyle = m.styles.ChangedLine
+			}
 		} else {
+			if m.isFlashed(item) {
+				textStyle = m.styles.FlashLine
+			}
 			if item.isEmptyValue {
 				content = iconEmptyValue
+			} else if m.isMasked(item) {
+				content = iconMasked
 			} else {
-				content = item.value
+				content = m.displayValue(item)
 			}
 		}
-		lineContent.WriteString(textStyle.Render(content))
+		if !item.isGroupHeader && m.isEditing(item) {
+			lineContent.WriteString(m.input.View())
+		} else {
+			lineContent.WriteString(textStyle.Render(content))
+		}
+		if item.isCompact && m.isEditing(item) {
+			padding := strings.Repeat(" ", max(0, valueColumn-lipgloss.Width(item.key)))
+			lineContent.WriteString(textStyle.Render(padding+" = ") + m.input.View())
+		} else if item.isCompact {
+			// Single occurrence shown on the header row
+			valueStyle := textStyle
+			value := m.displayValue(item)
+			if m.isMasked(item) {
+				value = iconMasked
+			}
+			if item.value == "" {
+				value = iconEmptyValue
+				if i != m.cursor {
+					valueStyle = m.styles.EmptyValueStyle.Faint(item.isDisabled)
+				}
+			}
+			if m.isFlashed(item) {
+				valueStyle = m.styles.FlashLine
+			}
+			padding := strings.Repeat(" ", max(0, valueColumn-lipgloss.Width(item.key)))
+			lineContent.WriteString(textStyle.Render(padding+" = ") + valueStyle.Render(value))
+		}
+		if item.isGroupHeader && required[item.key] {
+			lineContent.WriteString(m.styles.DisabledLine.Render(iconRequired))
+		}
+		if item.isGroupHeader && time.Now().Before(m.changedOnDisk[item.key]) {
+			lineContent.WriteString(m.styles.ChangedLine.Render(iconChanged))
+		}
+		if item.isGroupHeader && m.isConflicted(item.key) {
+			lineContent.WriteString(m.styles.ErrorMessage.Render(iconConflict))
+		}
+		if item.isGroupHeader && m.unsafeKeys[item.key] {
+			lineContent.WriteString(m.styles.ErrorMessage.Render(iconUnsafe))
+		}
+		if override, ok := m.overrides[item.key]; ok && item.isGroupHeader {
+			lineContent.WriteString(m.styles.OverrideStyle.Render(iconOverride + override + " (overridden, not saved)"))
+		}
+		if live, ok := m.envOverrides[item.key]; ok && item.isGroupHeader {
+			lineContent.WriteString(m.styles.ModifiedStatus.Render(iconEnvOverride + live))
+		}
+		if item.isGroupHeader && m.options.KeyCase.Apply(item.key) != item.key {
+			lineContent.WriteString(m.styles.ModifiedStatus.Render(iconKeyCase))
+		}
 
 		// Truncate line if it's too long
 		// TODO: Implement proper wrapping
-		truncatedLine := ansi.Truncate(lineContent.String(), m.width, "…")
+		truncatedLine := ansi.Truncate(lineContent.String(), m.listWidth(), "…")
+		if m.showTypes {
+			// Keep room for the right-aligned type column
+			label := ""
+			if !item.isGroupHeader || item.isCompact {
+				label = classifyValue(item.value)
+			}
+			budget := max(0, m.listWidth()-typeColumnWidth-1)
+			truncatedLine = ansi.Truncate(lineContent.String(), budget, "…")
+			padding := strings.Repeat(" ", max(0, budget-lipgloss.Width(truncatedLine)))
+			truncatedLine += padding + " " + m.styles.DisabledLine.Render(fmt.Sprintf("%*s", typeColumnWidth, label))
+		}
 
 		builder.WriteString(truncatedLine)
 		builder.WriteString("\n")
@@ -164,6 +456,169 @@ func (m *Model) renderList() string {
 	return finalStr
 }
 
+// displayValue returns the value of item as shown in the list: resolved if
+// toggled so for its line, literal otherwise. Multiline values are shown on
+// a single row. A resolved value is masked if it includes a masked one.
+func (m *Model) displayValue(item ListItem) string {
+	if item.groupIndex < 0 || item.valueIndex < 0 {
+		return singleRow(item.value)
+	}
+	line := m.parsedData.VariableGroups[m.parsedData.GroupOrder[item.groupIndex]].Lines[item.valueIndex]
+	if !m.resolvedLines[line] {
+		return singleRow(item.value)
+	}
+	if m.revealsMasked(line) {
+		return iconMasked + iconResolved
+	}
+	resolved, err := m.parsedData.ResolveLine(line)
+	if err != nil {
+		return singleRow(item.value) + iconUnsafe + " " + err.Error()
+	}
+	return singleRow(resolved) + iconResolved
+}
+
+// revealsMasked reports whether resolving line would show the value of a
+// masked variable, referenced directly or through other references.
+func (m *Model) revealsMasked(line *parser.Line) bool {
+	seen := make(map[string]bool)
+	var reveals func(line *parser.Line) bool
+	reveals = func(line *parser.Line) bool {
+		if line.QuoteType == '\'' {
+			return false // Taken literally
+		}
+		found := false
+		os.Expand(line.Value, func(name string) string {
+			if found || seen[name] {
+				return ""
+			}
+			seen[name] = true
+			group, ok := m.parsedData.VariableGroups[name]
+			if !ok {
+				return ""
+			}
+			if active := group.ActiveLine(); active != nil {
+				item := ListItem{groupIndex: slices.Index(m.parsedData.GroupOrder, name), valueIndex: -1, value: active.Value}
+				found = m.isMasked(item) || reveals(active)
+			}
+			return ""
+		})
+		return found
+	}
+	return reveals(line)
+}
+
+// singleRow replaces the line breaks of a multiline value with a visible mark.
+func singleRow(value string) string {
+	return strings.NewReplacer("\r\n", iconNewline, "\n", iconNewline, "\r", iconNewline).Replace(value)
+}
+
+// secretKeyRegex matches key names that usually hold sensitive values.
+var secretKeyRegex = regexp.MustCompile(`(?i)(KEY|SECRET|TOKEN|PASSWORD|PASSWD|PASS|PRIVATE|CREDENTIAL)`)
+
+// isSecretKey reports whether a variable is considered sensitive based on its
+// name: it contains one of parts, case-insensitively, or matches
+// secretKeyRegex if parts is nil.
+func isSecretKey(key string, parts []string) bool {
+	if parts == nil {
+		return secretKeyRegex.MatchString(key)
+	}
+	for _, part := range parts {
+		if part != "" && strings.Contains(strings.ToUpper(key), strings.ToUpper(part)) {
+			return true
+		}
+	}
+	return false
+}
+
+// isEditing reports whether the value line of item is being edited in place.
+func (m *Model) isEditing(item ListItem) bool {
+	if m.inputKind != inputValue || m.editLine == nil || item.valueIndex < 0 {
+		return false
+	}
+	group := m.parsedData.VariableGroups[m.parsedData.GroupOrder[item.groupIndex]]
+	return group.Lines[item.valueIndex] == m.editLine
+}
+
+// isFlashed reports whether the value line of item was just made active and is
+// still flashing.
+func (m *Model) isFlashed(item ListItem) bool {
+	if len(m.flashed) == 0 || item.valueIndex < 0 {
+		return false
+	}
+	group := m.parsedData.VariableGroups[m.parsedData.GroupOrder[item.groupIndex]]
+	return time.Now().Before(m.flashed[group.Lines[item.valueIndex]])
+}
+
+// isMasked reports whether the value of item must be hidden: its key looks
+// sensitive, or the value itself looks like a secret.
+func (m *Model) isMasked(item ListItem) bool {
+	if item.value == "" || item.groupIndex < 0 || m.peeking() {
+		return false
+	}
+	if m.maskedKeys[m.parsedData.GroupOrder[item.groupIndex]] {
+		return true
+	}
+	if !m.options.MaskSecrets {
+		return false
+	}
+	if _, ok := secrets.Detect(item.value); ok {
+		return true
+	}
+	return isSecretKey(m.parsedData.GroupOrder[item.groupIndex], m.options.SecretKeys)
+}
+
+// peeking reports whether masked values are temporarily revealed.
+func (m *Model) peeking() bool {
+	return time.Now().Before(m.peekUntil)
+}
+
+// typeColumnWidth is the width of the value type column, fitting the longest label.
+const typeColumnWidth = len("empty")
+
+// classifyValue infers the type of a value for the type column:
+// empty, bool, int, url, path or str.
+func classifyValue(value string) string {
+	switch strings.ToLower(value) {
+	case "":
+		return "empty"
+	case "true", "false", "yes", "no", "on", "off":
+		return "bool"
+	}
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return "int"
+	}
+	if u, err := url.Parse(value); err == nil && u.Scheme != "" && u.Host != "" {
+		return "url"
+	}
+	for _, prefix := range []string{"/", "./", "../", "~/"} {
+		if strings.HasPrefix(value, prefix) {
+			return "path"
+		}
+	}
+	return "str"
+}
+
+// minAlignedValueWidth is the room left for values when aligning them in a column.
+const minAlignedValueWidth = 10
+
+// alignColumn returns the width keys of compact rows are padded to so their
+// values line up, i.e. the longest such key. It returns 0 (no alignment) when
+// the column would leave less than minAlignedValueWidth for values.
+func alignColumn(items []ListItem, width int) int {
+	column := 0
+	for _, item := range items {
+		if item.isCompact {
+			column = max(column, lipgloss.Width(item.key))
+		}
+	}
+	// Pointer, checkbox and " = " come before the value
+	prefixWidth := len(iconPointer) + lipgloss.Width(iconCheckboxOn) + 1 + len(" = ")
+	if prefixWidth+column+minAlignedValueWidth > width {
+		return 0
+	}
+	return column
+}
+
 // ListItem represents a single renderable line in the TUI list.
 type ListItem struct {
 	// Common
@@ -174,7 +629,9 @@ type ListItem struct {
 
 	// Header specific
 	isGroupHeader bool
-	key           string
+	isFileHeader  bool   // Source file section header, only shown when grouping by file
+	isCompact     bool   // Header also showing the group's single value (compact mode)
+	key           string // Variable key, or file path for file headers
 
 	// Value specific
 	value        string
@@ -188,8 +645,97 @@ func (m *Model) buildListItems() []ListItem {
 		return items
 	}
 
-	for groupIdx, key := range m.parsedData.GroupOrder {
-		group := m.parsedData.VariableGroups[key]
+	if m.groupByFile {
+		// One section per source file, each listing the groups' lines from that file
+		for _, source := range m.parsedData.SourceFiles() {
+			header := len(items)
+			items = append(items, ListItem{
+				key:          source,
+				isFileHeader: true,
+				groupIndex:   -1,
+				valueIndex:   -1,
+			})
+			items = m.appendGroupItems(items, source)
+			if m.filter != "" && len(items) == header+1 {
+				items = items[:header] // No match in this file
+			}
+		}
+		return items
+	}
+
+	return m.appendGroupItems(items, "")
+}
+
+// groupDisplayOrder returns the indexes of the groups in the order they are
+// listed: pinned ones first, then the others in the current sort mode.
+func (m *Model) groupDisplayOrder() []int {
+	order := make([]int, 0, len(m.parsedData.GroupOrder))
+	isPinned := make(map[int]bool, len(m.pinned))
+	for _, key := range m.pinned {
+		if i := slices.Index(m.parsedData.GroupOrder, key); i != -1 && !isPinned[i] {
+			isPinned[i] = true
+			order = append(order, i)
+		}
+	}
+	pinnedCount := len(order)
+	for i := range m.parsedData.GroupOrder {
+		if !isPinned[i] {
+			order = append(order, i)
+		}
+	}
+	slices.SortStableFunc(order[pinnedCount:], func(a, b int) int {
+		return m.sortMode.compareKeys(m.parsedData.GroupOrder[a], m.parsedData.GroupOrder[b])
+	})
+	return order
+}
+
+// appendGroupItems appends the header and value items of every group.
+// If source is not empty, only the lines read from that file are considered.
+func (m *Model) appendGroupItems(items []ListItem, source string) []ListItem {
+	for _, groupIdx := range m.groupDisplayOrder() {
+		group := m.parsedData.VariableGroups[m.parsedData.GroupOrder[groupIdx]]
+		if !m.matchesFilter(group.Key) {
+			continue
+		}
+
+		var valueItems []ListItem
+		for valueIdx, line := range group.Lines {
+			if line.Type != parser.LineTypeVariable {
+				continue
+			}
+			if source != "" && line.SourceFile != source {
+				continue
+			}
+			valueItems = append(valueItems, ListItem{
+				value:         line.Value,
+				isDisabled:    !group.IsSelected,
+				isEmptyValue:  line.Value == "",
+				isGroupHeader: false,
+				groupIndex:    groupIdx,
+				valueIndex:    valueIdx,
+				isSelected:    group.SelectedLineIdx == valueIdx,
+			})
+		}
+		if source != "" && len(valueItems) == 0 {
+			// Group has no occurrence in this file
+			continue
+		}
+
+		if m.compact && len(valueItems) == 1 {
+			// Single occurrence, render it on the header row
+			value := valueItems[0]
+			items = append(items, ListItem{
+				key:           group.Key,
+				value:         value.value,
+				isDisabled:    !group.IsSelected,
+				isGroupHeader: true,
+				isCompact:     true,
+				groupIndex:    groupIdx,
+				valueIndex:    value.valueIndex,
+				isSelected:    group.IsSelected,
+			})
+			continue
+		}
 
 		// Group Header
 		items = append(items, ListItem{
@@ -202,21 +748,7 @@ func (m *Model) buildListItems() []ListItem {
 		})
 
 		// Value Lines
-		if len(group.Lines) > 0 {
-			for valueIdx, line := range group.Lines {
-				if line.Type == parser.LineTypeVariable {
-					items = append(items, ListItem{
-						value:         line.Value,
-						isDisabled:    !group.IsSelected,
-						isEmptyValue:  line.Value == "",
-						isGroupHeader: false,
-						groupIndex:    groupIdx,
-						valueIndex:    valueIdx,
-						isSelected:    group.SelectedLineIdx == valueIdx,
-					})
-				}
-			}
-		}
+		items = append(items, valueItems...)
 	}
 	return items
 }
@@ -0,0 +1,415 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/taha-yassine/sidem/internal/config"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestToggleResolvedOneLine(t *testing.T) {
+	m := newTestModel(t, "HOST=db\nURL=${HOST}/a\nOTHER=${HOST}/b\n", Options{})
+	m = press(m, "down", "down", "down", "r")
+
+	view := m.View()
+	if !strings.Contains(view, "db/a"+iconResolved) {
+		t.Errorf("focused line not resolved:\n%s", view)
+	}
+	if !strings.Contains(view, "${HOST}/b") || strings.Contains(view, "db/b") {
+		t.Errorf("other line resolved too:\n%s", view)
+	}
+
+	m = press(m, "r")
+	if view := m.View(); !strings.Contains(view, "${HOST}/a") {
+		t.Errorf("toggling again didn't restore the literal value:\n%s", view)
+	}
+}
+
+func TestResolvedValueKeepsSecretsMasked(t *testing.T) {
+	const content = "DB_PASSWORD=hunter2\nCRED=${DB_PASSWORD}\nURL=postgres://app:${CRED}@db\nHOST=${URL}\n"
+	m := newTestModel(t, content, Options{MaskSecrets: true})
+	// Resolve URL, referencing the password through CRED, and HOST, through URL
+	m = press(m, "down", "down", "down", "down", "down", "r", "down", "down", "r")
+	if len(m.resolvedLines) != 2 {
+		t.Fatalf("%d lines resolved, want 2", len(m.resolvedLines))
+	}
+
+	if view := m.View(); strings.Contains(view, "hunter2") {
+		t.Errorf("resolving revealed a masked value:\n%s", view)
+	}
+
+	// Without masking, the value is resolved
+	m = press(m, "M")
+	if view := m.View(); !strings.Contains(view, "postgres://app:hunter2@db"+iconResolved) {
+		t.Errorf("unmasked value not resolved:\n%s", view)
+	}
+}
+
+func TestInvalidLinesShown(t *testing.T) {
+	const content = "A=1\n1FOO=bar\n# comment\n"
+	m := newTestModel(t, content, Options{})
+
+	view := m.View()
+	if !strings.Contains(view, iconInvalid+"1 invalid line(s)") || !strings.Contains(view, "2: 1FOO=bar") {
+		t.Errorf("invalid line not listed:\n%s", view)
+	}
+	if strings.Contains(view, "# comment") {
+		t.Errorf("a real comment is listed as invalid:\n%s", view)
+	}
+
+	// Saving keeps the line verbatim
+	updated, _ := m.Update(m.fastSaveCmd()())
+	m = updated.(Model)
+	if written, _ := os.ReadFile(m.filePath); string(written) != content {
+		t.Errorf("saved %q, want the original", written)
+	}
+}
+
+func TestOverrideShownNotSaved(t *testing.T) {
+	m := newTestModel(t, "PORT=5432\nHOST=db\n", Options{Overrides: map[string]string{"PORT": "9999"}})
+
+	view := m.View()
+	if !strings.Contains(view, iconOverride+"9999 (overridden, not saved)") {
+		t.Errorf("override not shown:\n%s", view)
+	}
+	if strings.Count(view, "(overridden, not saved)") != 1 {
+		t.Errorf("override shown on another variable:\n%s", view)
+	}
+	if m.modified {
+		t.Error("an override marked the buffer modified")
+	}
+	if got := activeValue(t, m, "PORT"); got != "5432" {
+		t.Errorf("PORT = %q in the buffer, want the file's value", got)
+	}
+
+	// Promoting it makes it a change to save
+	m = press(m, "P")
+	if !m.modified || activeValue(t, m, "PORT") != "9999" {
+		t.Errorf("promoted override: modified %v, PORT = %q", m.modified, activeValue(t, m, "PORT"))
+	}
+	if strings.Contains(m.View(), "(overridden, not saved)") {
+		t.Error("promoted override still shown as not saved")
+	}
+}
+
+func TestWarnValueLength(t *testing.T) {
+	m := newTestModel(t, "SHORT=12345\nLONG=123456\nWIDE=ééééé\n", Options{WarnValueLength: 5})
+	tests := map[string]bool{"12345": false, "123456": true, "ééééé": false} // Counted in characters
+	for value, want := range tests {
+		if got := m.isLongValue(value); got != want {
+			t.Errorf("isLongValue(%q) = %v, want %v", value, got, want)
+		}
+	}
+	if view := m.View(); strings.Count(view, iconLongValue) != 1 {
+		t.Errorf("want one value flagged:\n%s", view)
+	}
+
+	// The footer explains the glyph while the long value is focused
+	m = press(m, "down", "down", "down")
+	if note := m.longValueNote(); !strings.Contains(note, "LONG is 6 characters long (over 5)") {
+		t.Errorf("footer note is %q", note)
+	}
+	if m = press(m, "down"); m.longValueNote() != "" {
+		t.Errorf("footer note %q on a short value", m.longValueNote())
+	}
+
+	m = newTestModel(t, "LONG=123456\n", Options{})
+	if m.isLongValue("123456") || strings.Contains(m.View(), iconLongValue) {
+		t.Error("value flagged without a threshold")
+	}
+}
+
+func TestHideSingleRadio(t *testing.T) {
+	m := newTestModel(t, "SINGLE=one\nMULTI=a\n# MULTI=b\n", Options{HideSingleRadio: true})
+	if !hasLine(m.View(), "      one") {
+		t.Errorf("single value rendered with a radio column:\n%s", m.View())
+	}
+	// Groups with a choice keep it
+	if !hasLine(m.View(), "      * a") {
+		t.Errorf("radio column hidden for a group with several values:\n%s", m.View())
+	}
+
+	m = press(m, "H")
+	if !hasLine(m.View(), "      * one") {
+		t.Errorf("toggling didn't bring the radio column back:\n%s", m.View())
+	}
+}
+
+func TestFooterHelpFollowsFocus(t *testing.T) {
+	m := newTestModel(t, "A=1\n# A=2\n", Options{})
+	header := m.footerHelp()
+	onValue := press(m, "down")
+	value := onValue.footerHelp()
+	if header == value {
+		t.Fatalf("same help on a header and a value line: %q", header)
+	}
+
+	tests := []struct {
+		focus     string
+		help      string
+		want, not []string
+	}{
+		{"header", header, []string{"Space/Enter: Toggle", "y: Copy key", "A: Copy all occurrences", "O: Sort by comment"}, []string{"e: Edit", "y: Copy value"}},
+		{"value line", value, []string{"Space/Enter: Select", "e: Edit", "y: Copy value", "r: Resolve"}, []string{"y: Copy key", "O: Sort by comment"}},
+	}
+	for _, tt := range tests {
+		for _, want := range tt.want {
+			if !strings.Contains(tt.help, want) {
+				t.Errorf("help on a %s lacks %q: %q", tt.focus, want, tt.help)
+			}
+		}
+		for _, not := range tt.not {
+			if strings.Contains(tt.help, not) {
+				t.Errorf("help on a %s has %q: %q", tt.focus, not, tt.help)
+			}
+		}
+	}
+
+	filtered := press(m, "/", "A", "enter")
+	if help := filtered.footerHelp(); !strings.Contains(help, "Esc: Clear filter") || strings.Contains(help, "/: Filter") {
+		t.Errorf("help while filtering is %q", help)
+	}
+	empty := newTestModel(t, "", Options{})
+	if help := empty.footerHelp(); !strings.Contains(help, "a: Add variable") || strings.Contains(help, "e: Edit") {
+		t.Errorf("help on an empty list is %q", help)
+	}
+}
+
+func TestScrollPercent(t *testing.T) {
+	tests := []struct {
+		name                   string
+		yOffset, height, total int
+		want                   int
+	}{
+		{"top", 0, 10, 50, 0},
+		{"middle", 20, 10, 50, 50},
+		{"bottom", 40, 10, 50, 100},
+		{"fits", 0, 10, 5, 100},
+		{"empty", 0, 10, 0, 100},
+		{"past the end", 45, 10, 50, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scrollPercent(tt.yOffset, tt.height, tt.total); got != tt.want {
+				t.Errorf("scrollPercent(%d, %d, %d) = %d, want %d", tt.yOffset, tt.height, tt.total, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScrollPosition(t *testing.T) {
+	var content strings.Builder
+	for i := range 50 {
+		fmt.Fprintf(&content, "K%02d=%d\n", i, i)
+	}
+	m := newTestModel(t, content.String(), Options{})
+	if got := m.scrollPosition(); got != "row 1/100 (0%)" {
+		t.Errorf("position %q at the top", got)
+	}
+	for range 99 {
+		m = press(m, "down")
+	}
+	if got := m.scrollPosition(); got != "row 100/100 (100%)" {
+		t.Errorf("position %q at the bottom", got)
+	}
+}
+
+func TestCompactSingleOccurrence(t *testing.T) {
+	m := newTestModel(t, "SINGLE=one\nMULTI=a\n# MULTI=b\n# OFF=x\nEMPTY=\n", Options{Compact: true})
+	view := m.View()
+	for _, want := range []string{"> [✓] SINGLE = one", "  [✓] MULTI", "      * a", "        b", "  [ ] OFF = x", "  [✓] EMPTY = <empty>"} {
+		if !hasLine(view, want) {
+			t.Errorf("no line %q in:\n%s", want, view)
+		}
+	}
+	if got := len(m.getCurrentListItems()); got != 6 {
+		t.Errorf("listed %d rows, want one per single-occurrence group", got)
+	}
+
+	// The one-line row acts on its value
+	m = press(m, " ")
+	if !hasLine(m.View(), "> [ ] SINGLE = one") {
+		t.Errorf("toggling the compact row didn't deactivate it:\n%s", m.View())
+	}
+
+	m = press(m, "c")
+	if !hasLine(m.View(), "> [ ] SINGLE") || !hasLine(m.View(), "  [ ] OFF") {
+		t.Errorf("expanded layout not restored:\n%s", m.View())
+	}
+}
+
+func TestAlignColumn(t *testing.T) {
+	items := []ListItem{
+		{key: "A", isGroupHeader: true, isCompact: true},
+		{key: "LONG_KEY", isGroupHeader: true, isCompact: true},
+		{key: "EVEN_LONGER_BUT_EXPANDED", isGroupHeader: true}, // Values on their own rows
+		{key: "EVEN_LONGER_BUT_EXPANDED", value: "x"},
+	}
+	prefix := len(iconPointer) + lipgloss.Width(iconCheckboxOn) + 1 + len(" = ")
+	fits := prefix + len("LONG_KEY") + minAlignedValueWidth
+
+	tests := []struct {
+		name  string
+		items []ListItem
+		width int
+		want  int
+	}{
+		{"longest compact key", items, 120, 8},
+		{"just fits", items, fits, 8},
+		{"too narrow", items, fits - 1, 0},
+		{"no compact rows", items[2:], 120, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := alignColumn(tt.items, tt.width); got != tt.want {
+				t.Errorf("alignColumn(width %d) = %d, want %d", tt.width, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAlignedValues(t *testing.T) {
+	m := newTestModel(t, "A=1\nLONG_KEY=2\nMULTI=a\n# MULTI=b\n", Options{Compact: true, AlignValues: true})
+	view := m.View()
+	for _, want := range []string{"> [✓] A        = 1", "  [✓] LONG_KEY = 2", "  [✓] MULTI"} {
+		if !hasLine(view, want) {
+			t.Errorf("no line %q in:\n%s", want, view)
+		}
+	}
+}
+
+func TestPeekRemasks(t *testing.T) {
+	m := newTestModel(t, "API_TOKEN=hunter2hunter2\nPORT=5432\n", Options{MaskSecrets: true})
+	if strings.Contains(m.View(), "hunter2") {
+		t.Fatalf("secret shown before peeking:\n%s", m.View())
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+	m = updated.(Model)
+	if cmd == nil {
+		t.Fatal("peek scheduled no re-mask")
+	}
+	if !strings.Contains(m.View(), "hunter2hunter2") {
+		t.Errorf("secret not revealed while peeking:\n%s", m.View())
+	}
+
+	// The timeout elapses
+	m.peekUntil = time.Now().Add(-time.Millisecond)
+	updated, _ = m.Update(peekEndMsg{})
+	if view := updated.(Model).View(); strings.Contains(view, "hunter2") {
+		t.Errorf("secret still shown after the peek timed out:\n%s", view)
+	}
+
+	// The next key press ends it sooner
+	m = press(m, "p")
+	if !m.peeking() {
+		t.Fatal("not peeking after p")
+	}
+	m = press(m, "down")
+	if strings.Contains(m.View(), "hunter2") {
+		t.Errorf("secret still shown after a key press:\n%s", m.View())
+	}
+}
+
+func TestSharedValuesView(t *testing.T) {
+	m := newTestModel(t, "API_TOKEN=hunter2\nOLD_TOKEN=hunter2\nDB_HOST=localhost\nCACHE_HOST=localhost\nPORT=5432\n", Options{MaskSecrets: true})
+
+	m = press(m, "=")
+	view := m.View()
+	for _, want := range []string{"Values shared by several variables", "localhost", "DB_HOST, CACHE_HOST", "API_TOKEN, OLD_TOKEN"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view lacks %q:\n%s", want, view)
+		}
+	}
+	for _, unwanted := range []string{"hunter2", "PORT"} {
+		if strings.Contains(view, unwanted) {
+			t.Errorf("view shows %q:\n%s", unwanted, view)
+		}
+	}
+
+	if view := press(m, "esc").View(); strings.Contains(view, "Values shared by several variables") {
+		t.Errorf("esc did not close the view:\n%s", view)
+	}
+
+	m = newTestModel(t, "A=1\nB=2\n", Options{})
+	if view := press(m, "=").View(); !strings.Contains(view, "No two active variables hold the same value.") {
+		t.Errorf("no empty notice:\n%s", view)
+	}
+}
+
+func TestClassifyValue(t *testing.T) {
+	tests := []struct {
+		value, want string
+	}{
+		{"", "empty"},
+		{"true", "bool"},
+		{"OFF", "bool"},
+		{"yes", "bool"},
+		{"5432", "int"},
+		{"-1", "int"},
+		{"1.5", "str"},
+		{"https://example.com/api", "url"},
+		{"postgres://user@db:5432/app", "url"},
+		{"mailto:someone", "str"},
+		{"/var/log", "path"},
+		{"./data", "path"},
+		{"../shared", "path"},
+		{"~/.config", "path"},
+		{"hello world", "str"},
+	}
+	for _, tt := range tests {
+		if got := classifyValue(tt.value); got != tt.want {
+			t.Errorf("classifyValue(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestTypeColumn(t *testing.T) {
+	m := newTestModel(t, "PORT=5432\nDEBUG=true\n", Options{})
+	if view := m.View(); strings.Contains(view, " int") {
+		t.Fatalf("types shown before T:\n%s", view)
+	}
+	view := press(m, "T").View()
+	for _, want := range []string{"int", "bool"} {
+		if !strings.Contains(view, " "+want) {
+			t.Errorf("no %s label in:\n%s", want, view)
+		}
+	}
+}
+
+func TestHeaderAccent(t *testing.T) {
+	custom := []config.HeaderAccent{
+		{Match: "", Color: "1"}, // Matches nothing
+		{Match: "QA", Color: "3"},
+		{Match: "qa-prod", Color: "9"}, // Shadowed by the rule above
+	}
+	tests := []struct {
+		path  string
+		rules []config.HeaderAccent
+		want  lipgloss.Color
+		ok    bool
+	}{
+		{".env.production", nil, "#ff5555", true},
+		{"/srv/app/PROD.env", nil, "#ff5555", true},
+		{".env.staging", nil, "#ffb86c", true},
+		{".env.development", nil, "#50fa7b", true},
+		{".env.local", nil, "#50fa7b", true},
+		{".env", nil, "", false},
+		{"/srv/prod/.env", nil, "", false}, // Only the file name counts
+		{".env.production", []config.HeaderAccent{}, "", false},
+		{".env.qa-prod", custom, "3", true},
+		{".env.production", custom, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := headerAccent(tt.path, tt.rules)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("headerAccent(%q, %v) = %q, %v, want %q, %v", tt.path, tt.rules, got, ok, tt.want, tt.ok)
+		}
+	}
+}
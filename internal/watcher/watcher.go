@@ -2,7 +2,12 @@ package watcher
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
 
 	// "log" // Removed for TUI cleanliness
 	"time"
@@ -11,8 +16,10 @@ import (
 	"github.com/fsnotify/fsnotify"
 )
 
-// FileChangedMsg is sent when the watched file is modified.
-type FileChangedMsg struct{}
+// FileChangedMsg is sent when one of the watched files is modified.
+type FileChangedMsg struct {
+	Path string // Watched path that changed, as given to Start
+}
 
 // WatcherErrMsg is sent when the watcher encounters an error.
 type WatcherErrMsg struct {
@@ -23,42 +30,102 @@ func (e WatcherErrMsg) Error() string {
 	return e.err.Error()
 }
 
+// DefaultPollInterval is how often watched files are checked when polling and
+// no interval is given.
+const DefaultPollInterval = time.Second
+
+// Retries of rewatch, waiting up to a second for a replaced file to reappear.
+const (
+	rewatchAttempts = 20
+	rewatchDelay    = 50 * time.Millisecond
+)
+
+// Options configures how files are watched.
+type Options struct {
+	Poll         bool          // Always poll instead of relying on file system events, e.g. on network filesystems
+	PollInterval time.Duration // How often files are checked when polling (DefaultPollInterval if 0)
+}
+
 // Watcher manages the file system watcher.
 type Watcher struct {
-	watcher *fsnotify.Watcher
-	Events  chan tea.Msg // Channel to send messages back to Bubble Tea
-	Errors  chan error   // Channel to send errors (raw errors)
+	watcher      *fsnotify.Watcher // nil when polling
+	pollInterval time.Duration
+	Events       chan tea.Msg // Channel to send messages back to Bubble Tea
+	Errors       chan error   // Channel to send errors (raw errors)
 }
 
-// New creates a new Watcher.
-func New() (*Watcher, error) {
+// New creates a new Watcher. It relies on file system events unless
+// opts.Poll is set, and falls back to polling when they aren't supported.
+func New(opts Options) (*Watcher, error) {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+	if opts.Poll {
+		return NewPolling(opts.PollInterval), nil
+	}
 	fsWatcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create file watcher: %w", err)
 	}
 	return &Watcher{
-		watcher: fsWatcher,
-		Events:  make(chan tea.Msg),
-		Errors:  make(chan error),
+		watcher:      fsWatcher,
+		pollInterval: opts.PollInterval,
+		Events:       make(chan tea.Msg),
+		Errors:       make(chan error),
 	}, nil
 }
 
-// Start begins watching the specified file.
+// NewPolling creates a Watcher that checks the modification time and size of
+// the watched files every interval, for filesystems where change events never
+// fire, such as network filesystems and some Docker bind mounts.
+func NewPolling(interval time.Duration) *Watcher {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	return &Watcher{
+		pollInterval: interval,
+		Events:       make(chan tea.Msg),
+		Errors:       make(chan error),
+	}
+}
+
+// Start begins watching the specified files, e.g. a .env file and the files it includes.
 // It runs in a goroutine and sends events/errors on the respective channels.
-func (w *Watcher) Start(ctx context.Context, filePath string) {
+// Changes are debounced per file, each reported with its path.
+// It falls back to polling if the filesystem doesn't support change events.
+// A file that doesn't exist yet is watched through its directory.
+func (w *Watcher) Start(ctx context.Context, filePaths ...string) {
 	go func() {
 		defer close(w.Events)
 		defer close(w.Errors)
+		if w.watcher == nil {
+			w.poll(ctx, filePaths)
+			return
+		}
 		defer w.watcher.Close()
 
-		err := w.watcher.Add(filePath)
-		if err != nil {
-			// Send error directly, let main loop format if needed
-			w.Errors <- fmt.Errorf("failed to add file %s to watcher: %w", filePath, err)
-			return
+		watched := make(map[string]string, len(filePaths)) // Cleaned path -> path as given
+		inDir := make(map[string]bool)                     // Paths watched through their directory
+		for _, filePath := range filePaths {
+			err := w.watcher.Add(filePath)
+			if errors.Is(err, fs.ErrNotExist) {
+				// Not created yet, e.g. a new file: watch its directory for it
+				err = w.watcher.Add(filepath.Dir(filePath))
+				inDir[filePath] = true
+			}
+			if isUnsupported(err) {
+				w.poll(ctx, filePaths)
+				return
+			}
+			if err != nil {
+				// Send error directly, let main loop format if needed
+				w.Errors <- fmt.Errorf("failed to add file %s to watcher: %w", filePath, err)
+				return
+			}
+			watched[filepath.Clean(filePath)] = filePath
 		}
 
-		var debounceTimer *time.Timer
+		debounceTimers := make(map[string]*time.Timer)
 		debounceDuration := 500 * time.Millisecond
 
 		for {
@@ -73,13 +140,29 @@ func (w *Watcher) Start(ctx context.Context, filePath string) {
 					return
 				}
 
-				if event.Has(fsnotify.Write) && event.Name == filePath {
-					if debounceTimer != nil {
-						debounceTimer.Stop()
+				path, ok := watched[filepath.Clean(event.Name)]
+				if !ok {
+					continue
+				}
+				if (event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename)) && !inDir[path] {
+					// Editors like vim save by renaming a new file over the
+					// old one, which drops the watch: watch the new file
+					if err := w.rewatch(ctx, path); err != nil {
+						if ctx.Err() != nil {
+							return
+						}
+						w.Errors <- err
+						continue
 					}
-					debounceTimer = time.AfterFunc(debounceDuration, func() {
-						// log.Printf("Watcher: Detected write event for %s", event.Name)
-						w.Events <- FileChangedMsg{}
+				}
+				created := event.Has(fsnotify.Create) && inDir[path]
+				if created || event.Has(fsnotify.Write) || event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+					if timer := debounceTimers[path]; timer != nil {
+						timer.Stop()
+					}
+					debounceTimers[path] = time.AfterFunc(debounceDuration, func() {
+						// log.Printf("Watcher: Detected change of %s", path)
+						w.Events <- FileChangedMsg{Path: path}
 					})
 				}
 
@@ -94,7 +177,82 @@ func (w *Watcher) Start(ctx context.Context, filePath string) {
 			}
 		}
 	}()
-	// log.Printf("Watcher: Started watching %s", filePath)
+	// log.Printf("Watcher: Started watching %v", filePaths)
+}
+
+// rewatch watches path again once a file reappears there, retrying for a short
+// while since it is usually replaced right after being removed or renamed.
+func (w *Watcher) rewatch(ctx context.Context, path string) error {
+	_ = w.watcher.Remove(path) // The watch may follow the renamed file
+	var err error
+	for range rewatchAttempts {
+		if err = w.watcher.Add(path); err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(rewatchDelay):
+		}
+	}
+	return fmt.Errorf("%s was removed or renamed and can no longer be watched: %w", path, err)
+}
+
+// fileState is what polling compares to detect a change.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// statFile returns the state of the file at path, the zero state if it can't be
+// read, e.g. while an editor replaces it.
+func statFile(path string) fileState {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileState{}
+	}
+	return fileState{modTime: info.ModTime(), size: info.Size()}
+}
+
+// poll checks the files every poll interval until ctx is done, reporting
+// each one whose modification time or size changed.
+func (w *Watcher) poll(ctx context.Context, filePaths []string) {
+	states := make(map[string]fileState, len(filePaths))
+	for _, filePath := range filePaths {
+		states[filePath] = statFile(filePath)
+	}
+
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, filePath := range filePaths {
+				state := statFile(filePath)
+				if state == states[filePath] {
+					continue
+				}
+				states[filePath] = state
+				select {
+				case w.Events <- FileChangedMsg{Path: filePath}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}
+}
+
+// isUnsupported reports whether err means the filesystem doesn't support
+// change events.
+func isUnsupported(err error) bool {
+	return errors.Is(err, errors.ErrUnsupported) ||
+		errors.Is(err, syscall.ENOSYS) ||
+		errors.Is(err, syscall.ENOTSUP) ||
+		errors.Is(err, syscall.EOPNOTSUPP)
 }
 
 // WatchFileCmd returns a command that listens for watcher events.
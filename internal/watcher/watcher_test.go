@@ -0,0 +1,52 @@
+package watcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// startupDelay leaves the watcher's goroutine time to register the files.
+const startupDelay = 100 * time.Millisecond
+
+func TestChangeTaggedWithPath(t *testing.T) {
+	polling := NewPolling(20 * time.Millisecond)
+	watching, err := New(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, w := range map[string]*Watcher{"events": watching, "polling": polling} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			main := filepath.Join(dir, ".env")
+			included := filepath.Join(dir, ".env.shared")
+			for _, path := range []string{main, included} {
+				if err := os.WriteFile(path, []byte("A=1\n"), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			w.Start(ctx, main, included)
+			time.Sleep(startupDelay)
+
+			if err := os.WriteFile(included, []byte("A=2\nB=3\n"), 0600); err != nil {
+				t.Fatal(err)
+			}
+			msgs := make(chan any, 1)
+			go func() { msgs <- w.WatchFileCmd()() }()
+			select {
+			case msg := <-msgs:
+				if got, ok := msg.(FileChangedMsg); !ok || got.Path != included {
+					t.Errorf("got %#v, want a change of %s", msg, included)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("no change reported")
+			}
+		})
+	}
+}